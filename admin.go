@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	kAdminRoomGroupsPath   string = "/admin/room-groups"
+	kAdminMappingCheckPath string = "/admin/mapping-check"
+)
+
+// adminUsers maps admin user names to the SHA-256 of their password, from
+// ADMIN_USERS ("name:password,..."). The admin API is disabled without any.
+var adminUsers = map[string][32]byte{}
+
+// adminError is an admin request that can't be done as asked.
+type adminError struct {
+	status  int
+	message string
+}
+
+func (e *adminError) Error() string { return e.message }
+
+func loadAdminUsers(value string) {
+	for _, user := range strings.Split(value, ",") {
+		name, password, ok := strings.Cut(strings.TrimSpace(user), ":")
+		if !ok || name == "" || password == "" {
+			continue
+		}
+		adminUsers[name] = sha256.Sum256([]byte(password))
+	}
+}
+
+// adminUser checks the request's basic auth credentials, returning the user
+// name.
+func adminUser(r *http.Request) (string, bool) {
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return "", false
+	}
+	expected, found := adminUsers[name]
+	given := sha256.Sum256([]byte(password))
+	if subtle.ConstantTimeCompare(given[:], expected[:]) != 1 || !found {
+		return "", false
+	}
+	return name, true
+}
+
+// authorizeAdmin returns the admin user making the request, or writes the
+// error response if there isn't one.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if len(adminUsers) == 0 {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return "", false
+	}
+	user, ok := adminUser(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="fbds admin", charset="UTF-8"`)
+		writeAPIError(w, http.StatusUnauthorized, "unauthorized")
+		return "", false
+	}
+	return user, true
+}
+
+// adminRoomGroups manages the room groups in the mapping file:
+//
+//	GET    /admin/room-groups
+//	POST   /admin/room-groups
+//	GET    /admin/room-groups/{name}
+//	PUT    /admin/room-groups/{name}
+//	DELETE /admin/room-groups/{name}
+func adminRoomGroups(w http.ResponseWriter, r *http.Request) {
+	user, ok := authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	name, err := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(r.URL.EscapedPath(), kAdminRoomGroupsPath), "/"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid path: "+err.Error())
+		return
+	}
+	entry := MappingAuditEntry{User: user, RemoteAddr: r.RemoteAddr, RoomGroup: name}
+
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		roomGroups, err := readJSONMapping(mappingPath)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		if roomGroups == nil {
+			roomGroups = []RoomGroups{}
+		}
+		writeJSON(w, http.StatusOK, roomGroups)
+
+	case name == "" && r.Method == http.MethodPost:
+		roomGroup, err := decodeRoomGroup(r, "")
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		entry.Action, entry.RoomGroup, entry.After = "created", roomGroup.RoomGroup, &roomGroup
+		err = updateMapping(&entry, func(roomGroups []RoomGroups) ([]RoomGroups, error) {
+			if findRoomGroup(roomGroups, roomGroup.RoomGroup) >= 0 {
+				return nil, &adminError{http.StatusConflict, "room group " + roomGroup.RoomGroup + " already exists"}
+			}
+			return append(roomGroups, roomGroup), nil
+		})
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, roomGroup)
+
+	case name != "" && r.Method == http.MethodGet:
+		roomGroups, err := readJSONMapping(mappingPath)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		i := findRoomGroup(roomGroups, name)
+		if i < 0 {
+			writeAPIError(w, http.StatusNotFound, "room group "+name+" not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, roomGroups[i])
+
+	case name != "" && r.Method == http.MethodPut:
+		roomGroup, err := decodeRoomGroup(r, name)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		entry.Action, entry.After = "updated", &roomGroup
+		err = updateMapping(&entry, func(roomGroups []RoomGroups) ([]RoomGroups, error) {
+			i := findRoomGroup(roomGroups, name)
+			if i < 0 {
+				return nil, &adminError{http.StatusNotFound, "room group " + name + " not found"}
+			}
+			before := roomGroups[i]
+			entry.Before = &before
+			roomGroups[i] = roomGroup
+			return roomGroups, nil
+		})
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, roomGroup)
+
+	case name != "" && r.Method == http.MethodDelete:
+		entry.Action = "deleted"
+		err = updateMapping(&entry, func(roomGroups []RoomGroups) ([]RoomGroups, error) {
+			i := findRoomGroup(roomGroups, name)
+			if i < 0 {
+				return nil, &adminError{http.StatusNotFound, "room group " + name + " not found"}
+			}
+			before := roomGroups[i]
+			entry.Before = &before
+			return append(roomGroups[:i], roomGroups[i+1:]...), nil
+		})
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// adminMappingCheck checks the mapping file against the function rooms at a
+// location, as -check-mapping does:
+//
+//	GET /admin/mapping-check?location-id=
+func adminMappingCheck(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorizeAdmin(w, r); !ok {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	locationID := r.URL.Query().Get("location-id")
+	if locationID == "" {
+		writeAPIError(w, http.StatusBadRequest, "location-id must be provided")
+		return
+	}
+
+	roomGroups, err := readJSONMapping(mappingPath)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	report, err := checkMapping(r.Context(), locationID, roomGroups)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// decodeRoomGroup reads a room group from the request body. When name is set
+// the body's RoomGroup must match it, or be left out.
+func decodeRoomGroup(r *http.Request, name string) (RoomGroups, error) {
+	var roomGroup RoomGroups
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&roomGroup); err != nil {
+		return RoomGroups{}, &adminError{http.StatusBadRequest, "invalid room group: " + err.Error()}
+	}
+	if name != "" {
+		if roomGroup.RoomGroup != "" && roomGroup.RoomGroup != name {
+			return RoomGroups{}, &adminError{http.StatusBadRequest, "RoomGroup doesn't match the path, room groups can't be renamed"}
+		}
+		roomGroup.RoomGroup = name
+	}
+	if err := validateRoomGroup(roomGroup); err != nil {
+		return RoomGroups{}, &adminError{http.StatusUnprocessableEntity, err.Error()}
+	}
+	return roomGroup, nil
+}
+
+func findRoomGroup(roomGroups []RoomGroups, name string) int {
+	for i, roomGroup := range roomGroups {
+		if roomGroup.RoomGroup == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	var requestErr *adminError
+	if errors.As(err, &requestErr) {
+		writeAPIError(w, requestErr.status, requestErr.message)
+		return
+	}
+	LogError(err)
+	writeAPIError(w, http.StatusInternalServerError, err.Error())
+}
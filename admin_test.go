@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"example.com/m/v2/ahws"
+)
+
+func TestAdminMappingCheck(t *testing.T) {
+	var exports int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/2.0/OAuth2"):
+			w.Write([]byte(`{"access_token":"token","expires_in":900,"refresh_token":"refresh","token_type":"bearer"}`))
+		case r.URL.Path == "/api/V2/FunctionRoom/Export":
+			atomic.AddInt32(&exports, 1)
+			w.Write([]byte(`[{"Name":"Ballroom A","ExternalId":"E1","LocationId":"LOC1"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	savedClient, savedUsers, savedPath := apiClient, adminUsers, mappingPath
+	defer func() { apiClient, adminUsers, mappingPath = savedClient, savedUsers, savedPath }()
+	apiClient = ahws.NewClient(ahws.Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}, server.URL, nil, nil)
+	defer apiClient.Close()
+	adminUsers = map[string][32]byte{}
+	mappingPath = filepath.Join(t.TempDir(), "mapping.json")
+	if err := writeJSONMapping(mappingPath, []RoomGroups{{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A", "Balroom B", "id:E1", "id:Ballroom A"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(user string, password string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, kAdminMappingCheckPath+"?location-id=LOC1", nil)
+		if user != "" {
+			r.SetBasicAuth(user, password)
+		}
+		w := httptest.NewRecorder()
+		adminMappingCheck(w, r)
+		return w
+	}
+
+	if w := check("", ""); w.Code != http.StatusNotFound {
+		t.Errorf("got %d without any admin users, want 404", w.Code)
+	}
+	loadAdminUsers("admin:secret")
+	if w := check("admin", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d with the wrong password, want 401", w.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		w := check("admin", "secret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("got %d: %s", w.Code, w.Body)
+		}
+		var report MappingReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Valid || len(report.UnknownRooms) != 3 {
+			t.Errorf("got %+v, want the group name, Balroom B and ID Ballroom A unknown", report)
+		}
+	}
+	if exports := atomic.LoadInt32(&exports); exports != 1 {
+		t.Errorf("exported the function rooms %d times, want them cached", exports)
+	}
+
+	// The check is no longer on the unauthenticated API.
+	w := httptest.NewRecorder()
+	apiLocations(w, httptest.NewRequest(http.MethodGet, kAPILocationsPath+"LOC1/mapping", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got %d from the API, want 404", w.Code)
+	}
+}
+
+func TestAdminRoomGroups(t *testing.T) {
+	dir := t.TempDir()
+	savedUsers, savedPath, savedAuditPath := adminUsers, mappingPath, mappingAuditPath
+	defer func() { adminUsers, mappingPath, mappingAuditPath = savedUsers, savedPath, savedAuditPath }()
+	defer func() {
+		mappingMu.Lock()
+		applyMapping(nil)
+		mappingMu.Unlock()
+	}()
+	adminUsers = map[string][32]byte{}
+	loadAdminUsers("admin:secret")
+	mappingPath = filepath.Join(dir, "mapping.json")
+	mappingAuditPath = filepath.Join(dir, "mapping-audit.log")
+
+	request := func(method string, name string, body string, auth bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, kAdminRoomGroupsPath+name, strings.NewReader(body))
+		if auth {
+			r.SetBasicAuth("admin", "secret")
+		}
+		w := httptest.NewRecorder()
+		adminRoomGroups(w, r)
+		return w
+	}
+	audit := func() []MappingAuditEntry {
+		file, err := os.Open(mappingAuditPath)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		var entries []MappingAuditEntry
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var entry MappingAuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	mapping := func() []RoomGroups {
+		roomGroups, err := readJSONMapping(mappingPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return roomGroups
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		noAuth       bool
+		status       int
+		action       string
+		want         []RoomGroups
+	}{
+		{name: "no credentials", method: http.MethodGet, noAuth: true, status: http.StatusUnauthorized},
+		{name: "no credentials to change", method: http.MethodPost, body: `{"RoomGroup":"Ballroom","Rooms":["A"]}`, noAuth: true, status: http.StatusUnauthorized},
+		{
+			name: "create", method: http.MethodPost, body: `{"RoomGroup":"Ballroom","Rooms":["Ballroom A","Ballroom B"]}`,
+			status: http.StatusCreated, action: "created",
+			want: []RoomGroups{{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A", "Ballroom B"}}},
+		},
+		{name: "duplicate", method: http.MethodPost, body: `{"RoomGroup":"Ballroom","Rooms":["Ballroom C"]}`, status: http.StatusConflict},
+		{name: "malformed body", method: http.MethodPost, body: `{"RoomGroup":`, status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPost, body: `{"Name":"Salon","Rooms":["Salon 1"]}`, status: http.StatusBadRequest},
+		{name: "no rooms", method: http.MethodPost, body: `{"RoomGroup":"Salon","Rooms":[]}`, status: http.StatusUnprocessableEntity},
+		{name: "rename", method: http.MethodPut, path: "/Ballroom", body: `{"RoomGroup":"Salon","Rooms":["Ballroom A"]}`, status: http.StatusBadRequest},
+		{name: "update missing", method: http.MethodPut, path: "/Salon", body: `{"Rooms":["Salon 1"]}`, status: http.StatusNotFound},
+		{
+			name: "update", method: http.MethodPut, path: "/Ballroom", body: `{"Rooms":["Ballroom A"]}`,
+			status: http.StatusOK, action: "updated",
+			want: []RoomGroups{{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A"}}},
+		},
+		{name: "delete missing", method: http.MethodDelete, path: "/Salon", status: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, path: "/Ballroom", status: http.StatusNoContent, action: "deleted"},
+	}
+	var actions []string
+	var want []RoomGroups
+	for _, test := range tests {
+		before, _ := os.Stat(mappingPath)
+
+		w := request(test.method, test.path, test.body, !test.noAuth)
+		if w.Code != test.status {
+			t.Fatalf("%s: got %d, want %d: %s", test.name, w.Code, test.status, w.Body)
+		}
+
+		after, _ := os.Stat(mappingPath)
+		if test.action != "" {
+			actions = append(actions, test.action)
+			want = test.want
+			if before != nil && os.SameFile(before, after) {
+				t.Errorf("%s: mapping.json was written in place, not replaced", test.name)
+			}
+		} else if before != nil && !os.SameFile(before, after) {
+			t.Errorf("%s: mapping.json was replaced by a rejected change", test.name)
+		}
+
+		var got []string
+		for _, entry := range audit() {
+			got = append(got, entry.Action)
+		}
+		if !reflect.DeepEqual(got, actions) {
+			t.Errorf("%s: audit log has %v, want %v", test.name, got, actions)
+		}
+		if roomGroups := mapping(); !reflect.DeepEqual(roomGroups, want) && len(roomGroups)+len(want) > 0 {
+			t.Errorf("%s: mapping is %+v, want %+v", test.name, roomGroups, want)
+		}
+	}
+
+	entries := audit()
+	if last := entries[len(entries)-1]; last.User != "admin" || last.RoomGroup != "Ballroom" || last.Before == nil || last.After != nil {
+		t.Errorf("delete was recorded as %+v", last)
+	}
+	if _, found := lookupRoomGroup("Ballroom"); found {
+		t.Error("deleted room group is still in effect")
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("got %d files, want only mapping.json and the audit log", len(files))
+	}
+}
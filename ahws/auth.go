@@ -0,0 +1,79 @@
+package ahws
+
+import "context"
+
+// Authenticate requests a new access token using the client's credentials.
+// Concurrent calls share a single request.
+func (c *Client) Authenticate(ctx context.Context) (AuthTokenResponse, error) {
+	response, err := c.flights.do(ctx, "Authenticate", func(ctx context.Context) (any, error) {
+		return c.authenticate(ctx)
+	})
+	if err != nil {
+		return AuthTokenResponse{}, err
+	}
+	return response.(AuthTokenResponse), nil
+}
+
+func (c *Client) authenticate(ctx context.Context) (AuthTokenResponse, error) {
+	authRequest := AuthTokenRequest{
+		ClientID:     c.credentials.ClientID,
+		ClientSecret: c.credentials.ClientSecret,
+		Username:     c.credentials.Username,
+		Password:     c.credentials.Password,
+		GrantType:    "password",
+	}
+
+	var authTokenResponse AuthTokenResponse
+
+	// Encode the dictionary as JSON.
+	jsonRequestBody, err := c.marshalAndLogWithErrorOutput(authRequest)
+	if err != nil {
+		return authTokenResponse, err
+	}
+
+	body, err := c.httpPostJSON(ctx, kAccessTokenPath, jsonRequestBody)
+	if err != nil {
+		return authTokenResponse, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &authTokenResponse)
+	if err != nil {
+		return authTokenResponse, err
+	}
+	authTokenResponse.ExpiresAt = c.expiresAt(authTokenResponse.ExpiresIn)
+	c.cacheAuthTokenResponse(authTokenResponse)
+	return authTokenResponse, nil
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access token.
+func (c *Client) RefreshAccessToken(ctx context.Context, refreshAccessToken string) (AuthTokenResponse, error) {
+	refreshTokenRequest := RefreshAuthTokenRequest{
+		GrantType:    "refresh_token",
+		RefreshToken: refreshAccessToken,
+	}
+
+	var responseData AuthTokenResponse
+
+	// Encode the dictionary as JSON.
+	jsonRequestBody, err := c.marshalAndLogWithErrorOutput(refreshTokenRequest)
+	if err != nil {
+		return responseData, err
+	}
+
+	body, err := c.httpPostJSON(ctx, kRefreshAccessTokenPath, jsonRequestBody)
+	if err != nil {
+		return responseData, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &responseData)
+	if err != nil {
+		return responseData, err
+	}
+	if c.LogLevel >= LogLevelVerbose {
+		c.logPrettyPrintJSON(responseData)
+	}
+
+	responseData.ExpiresAt = c.expiresAt(responseData.ExpiresIn)
+	c.cacheAuthTokenResponse(responseData)
+	return responseData, nil
+}
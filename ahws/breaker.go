@@ -0,0 +1,89 @@
+package ahws
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting AHWS while the circuit
+// breaker is open after repeated failures.
+var ErrCircuitOpen = errors.New("ahws: circuit breaker open, AHWS unavailable")
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops requests to AHWS after threshold consecutive failures.
+// Once cooldown has passed a single trial request is let through, if it
+// succeeds the circuit closes again.
+type circuitBreaker struct {
+	// log is the client's debugPrint, nil in tests.
+	log func(s any, logLevel int)
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+func (b *circuitBreaker) allow(cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < cooldown {
+			return false
+		}
+		b.debugPrint("circuit breaker half-open, sending trial request", LogLevelErrors)
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// The trial request is still in flight.
+		return false
+	}
+	return true
+}
+
+func (b *circuitBreaker) record(success bool, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		if b.state != circuitClosed {
+			b.debugPrint("circuit breaker closed", LogLevelErrors)
+		}
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || (threshold > 0 && b.failures >= threshold) {
+		if b.state != circuitOpen {
+			b.debugPrint("circuit breaker open", LogLevelErrors)
+		}
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release gives up a trial request that ended without telling us anything
+// about AHWS, for example because the caller went away.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+		b.openedAt = time.Time{}
+	}
+}
+
+func (b *circuitBreaker) debugPrint(s any, logLevel int) {
+	if b.log != nil {
+		b.log(s, logLevel)
+	}
+}
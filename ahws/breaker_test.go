@@ -0,0 +1,154 @@
+package ahws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterFailures(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	c.MaxRetries = 0
+	c.BreakerFailures = 3
+	c.BreakerCooldown = time.Hour
+
+	for i := 0; i < 3; i++ {
+		if _, err := get(t, c, "/ping"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: circuit opened early", i+1)
+		}
+	}
+	_, err := get(t, c, "/ping")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v, want ErrCircuitOpen", err)
+	}
+	if !IsUnavailable(err) {
+		t.Error("ErrCircuitOpen should count as unavailable")
+	}
+	if calls != 3 {
+		t.Errorf("got %d requests, want 3", calls)
+	}
+}
+
+func TestBreakerSendsOneTrialRequest(t *testing.T) {
+	var calls int32
+	var failing atomic.Bool
+	failing.Store(true)
+	var once sync.Once
+	trial := make(chan struct{})
+	finishTrial := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		once.Do(func() {
+			close(trial)
+			<-finishTrial
+		})
+		w.Write([]byte("ok"))
+	})
+	c.MaxRetries = 0
+	c.BreakerFailures = 2
+	c.BreakerCooldown = time.Millisecond * 50
+
+	get(t, c, "/ping")
+	get(t, c, "/ping")
+	if _, err := get(t, c, "/ping"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(c.BreakerCooldown * 2)
+	failing.Store(false)
+	atomic.StoreInt32(&calls, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := get(t, c, "/ping"); err != nil {
+			t.Errorf("trial request: %v", err)
+		}
+	}()
+	<-trial
+
+	// Everything else fails fast while the trial is in flight.
+	for i := 0; i < 5; i++ {
+		if _, err := get(t, c, "/ping"); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("got error %v during the trial, want ErrCircuitOpen", err)
+		}
+	}
+	close(finishTrial)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("got %d requests while half-open, want 1", calls)
+	}
+	if _, err := get(t, c, "/ping"); err != nil {
+		t.Errorf("got error %v after a successful trial, want the circuit closed", err)
+	}
+}
+
+func TestBreakerFailedTrialReopens(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.MaxRetries = 0
+	c.BreakerFailures = 1
+	c.BreakerCooldown = time.Millisecond * 50
+
+	get(t, c, "/ping")
+	time.Sleep(c.BreakerCooldown * 2)
+	if _, err := get(t, c, "/ping"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("trial request wasn't sent")
+	}
+	if _, err := get(t, c, "/ping"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got error %v after a failed trial, want ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d requests, want 2", calls)
+	}
+}
+
+func TestBreakerReleasesAbandonedTrial(t *testing.T) {
+	var calls int32
+	var failing atomic.Bool
+	failing.Store(true)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	c.MaxRetries = 0
+	c.BreakerFailures = 1
+	c.BreakerCooldown = time.Millisecond * 50
+
+	get(t, c, "/ping")
+	time.Sleep(c.BreakerCooldown * 2)
+
+	// The caller going away before the trial is sent says nothing about AHWS.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/ping", nil)
+	if _, err := c.DoHTTPRequest(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+
+	failing.Store(false)
+	if _, err := get(t, c, "/ping"); err != nil {
+		t.Errorf("got error %v, want another trial request let through", err)
+	}
+}
@@ -0,0 +1,26 @@
+package ahws
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// requestCacheKey derives a cache key from every exported field of request,
+// so two requests that differ in any way, e.g. FunctionRoomGroupId or
+// MaxResultCount, never share a cache entry. Values are escaped so they can't
+// run into the separators.
+func requestCacheKey(prefix string, request any) string {
+	v := reflect.ValueOf(request)
+	t := v.Type()
+
+	key := prefix
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key += ":" + field.Name + "=" + url.QueryEscape(fmt.Sprint(v.Field(i).Interface()))
+	}
+	return key
+}
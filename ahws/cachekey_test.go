@@ -0,0 +1,111 @@
+package ahws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDefiniteEventsCacheKey(t *testing.T) {
+	base := DefiniteEventSearchRequest{
+		BookingEventDateTimeBegin: "2026-10-18",
+		BookingEventDateTimeEnd:   "2026-10-19",
+		LocationId:                "L1",
+	}
+	with := func(change func(*DefiniteEventSearchRequest)) DefiniteEventSearchRequest {
+		request := base
+		change(&request)
+		return request
+	}
+
+	tests := []struct {
+		name string
+		a, b DefiniteEventSearchRequest
+	}{
+		{"different group", with(func(r *DefiniteEventSearchRequest) { r.FunctionRoomGroupId = "G1" }), with(func(r *DefiniteEventSearchRequest) { r.FunctionRoomGroupId = "G2" })},
+		{"no group and a group", base, with(func(r *DefiniteEventSearchRequest) { r.FunctionRoomGroupId = "G1" })},
+		{"different MaxResultCount", with(func(r *DefiniteEventSearchRequest) { r.MaxResultCount = 10 }), with(func(r *DefiniteEventSearchRequest) { r.MaxResultCount = 20 })},
+		{"different location", base, with(func(r *DefiniteEventSearchRequest) { r.LocationId = "L2" })},
+		{"different range", base, with(func(r *DefiniteEventSearchRequest) { r.BookingEventDateTimeEnd = "2026-10-20" })},
+		// Unescaped, both would be ...:FunctionRoomGroupId=A:LocationId=B...
+		{"separators in a group", with(func(r *DefiniteEventSearchRequest) { r.FunctionRoomGroupId = "A:LocationId=L1" }), with(func(r *DefiniteEventSearchRequest) { r.FunctionRoomGroupId = "A" })},
+		{"separators across fields", with(func(r *DefiniteEventSearchRequest) {
+			r.FunctionRoomGroupId = "G=1"
+			r.LocationId = "L:2"
+		}), with(func(r *DefiniteEventSearchRequest) {
+			r.FunctionRoomGroupId = "G"
+			r.LocationId = "1:L:2"
+		})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := definiteEventsCacheKey(test.a), definiteEventsCacheKey(test.b)
+			if a == b {
+				t.Errorf("both requests have cache key %s", a)
+			}
+		})
+	}
+
+	if definiteEventsCacheKey(base) != definiteEventsCacheKey(with(func(*DefiniteEventSearchRequest) {})) {
+		t.Error("equal requests have different cache keys")
+	}
+}
+
+func TestRequestCacheKey(t *testing.T) {
+	type request struct {
+		A        string
+		B        int
+		internal string
+	}
+	tests := []struct {
+		request request
+		want    string
+	}{
+		{request{}, "Prefix:A=:B=0"},
+		{request{A: "x", B: 2}, "Prefix:A=x:B=2"},
+		{request{A: "a:b=c d"}, "Prefix:A=a%3Ab%3Dc+d:B=0"},
+		{request{A: "x", internal: "ignored"}, "Prefix:A=x:B=0"},
+	}
+	for _, test := range tests {
+		if got := requestCacheKey("Prefix", test.request); got != test.want {
+			t.Errorf("requestCacheKey(%+v) = %s, want %s", test.request, got, test.want)
+		}
+	}
+}
+
+func TestSearchDefiniteEventsByGroup(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var request DefiniteEventSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode([]DefiniteEventSearchResponse{{Name: "Event in " + request.FunctionRoomGroupId}})
+	})
+
+	search := func(groupID string) string {
+		t.Helper()
+		result, err := c.SearchDefiniteEvents(context.Background(), DefiniteEventSearchRequest{
+			BookingEventDateTimeBegin: "2026-10-18",
+			BookingEventDateTimeEnd:   "2026-10-19",
+			LocationId:                "L1",
+			FunctionRoomGroupId:       groupID,
+		})
+		if err != nil || len(result.Events) != 1 {
+			t.Fatalf("group %s: got %v, %v", groupID, result.Events, err)
+		}
+		return result.Events[0].Name
+	}
+
+	for _, groupID := range []string{"G1", "G2", "", "G1", "G2", ""} {
+		if got, want := search(groupID), "Event in "+groupID; got != want {
+			t.Errorf("group %q: got %q, want %q", groupID, got, want)
+		}
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Errorf("got %d upstream searches, want one for each group", calls)
+	}
+}
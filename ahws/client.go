@@ -0,0 +1,131 @@
+// Package ahws is a client for the Amadeus Hospitality Web Services (formerly
+// Newmarket) Events API.
+package ahws
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/v2/cachestore"
+)
+
+const (
+	// DefaultBaseURL string = "https://api-release.amadeus-hospitality.com"
+	// kAuthPath      string = "/release/2.0/OAuth2"
+	// kAPIPath       string = "/api/release"
+	DefaultBaseURL               string        = "https://api.newmarketinc.com"
+	kAuthPath                    string        = "/2.0/OAuth2"
+	kAPIPath                     string        = "/api"
+	kAccessTokenPath             string        = kAuthPath + "/AccessToken"
+	kRefreshAccessTokenPath      string        = kAuthPath + "/RefreshAccessToken"
+	kLocationSearchPath          string        = kAPIPath + "/Location/Search"
+	kLocationsByExternalID       string        = kAPIPath + "/location/ExternalLocationId"
+	kLocationsByID               string        = kAPIPath + "/location/LocationId"
+	kFunctionRoomGroupSearchPath string        = kAPIPath + "/functionroomgroup/Search"
+	kFunctionRoomsSearchPath     string        = kAPIPath + "/functionroom/Search"
+	kFunctionRoomsExportPath     string        = kAPIPath + "/V2/FunctionRoom/Export"
+	kDefiniteEventSearchPath     string        = kAPIPath + "/bookingEvent/DefiniteEventSearch"
+	kTTL                         time.Duration = time.Minute * 15
+)
+
+const (
+	CacheLevelNone = iota
+	CacheLevelAuth
+	CacheLevelAll
+)
+
+type (
+	// Credentials used to authenticate against AHWS.
+	Credentials struct {
+		ClientID        string
+		ClientSecret    string
+		Username        string
+		Password        string
+		SubscriptionKey string
+	}
+
+	// Client talks to a single AHWS account. Responses are cached in the
+	// provided cache according to CacheLevel.
+	Client struct {
+		CacheLevel int
+		// TokenRefreshBefore is how long before expiry the access token is
+		// refreshed in the background.
+		TokenRefreshBefore time.Duration
+		// RequestTimeout limits each attempt at an HTTP request.
+		RequestTimeout time.Duration
+		// MaxRetries is how many times a request that failed because AHWS
+		// was unavailable is retried, waiting between RetryBaseDelay and
+		// RetryMaxDelay with exponential backoff.
+		MaxRetries     int
+		RetryBaseDelay time.Duration
+		RetryMaxDelay  time.Duration
+		// BreakerFailures consecutive failures open the circuit breaker for
+		// BreakerCooldown, during which requests fail with ErrCircuitOpen.
+		BreakerFailures int
+		BreakerCooldown time.Duration
+		// StaleTTL is how long responses are kept after they stop being
+		// fresh, see CachedResponse.
+		StaleTTL time.Duration
+		// Logger gets the client's log output up to LogLevel, nil discards
+		// it.
+		Logger   *log.Logger
+		LogLevel int
+
+		credentials Credentials
+		baseURL     string
+		httpClient  *http.Client
+		cache       cachestore.Cache
+		breaker     circuitBreaker
+
+		revalidating sync.Map
+
+		flights       flightGroup
+		tokenMu       sync.Mutex
+		tokenTimer    *time.Timer
+		tokenTimerFor int64
+	}
+)
+
+// NewClient returns a Client for baseURL. A nil httpClient uses
+// http.DefaultClient, and a nil apiCache gets a private in-memory cache.
+func NewClient(credentials Credentials, baseURL string, httpClient *http.Client, apiCache cachestore.Cache) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if apiCache == nil {
+		apiCache = cachestore.NewMemory(kTTL, kTTL+5*time.Minute)
+	}
+	c := &Client{
+		CacheLevel:         CacheLevelAll,
+		TokenRefreshBefore: kDefaultTokenRefreshBefore,
+		RequestTimeout:     kDefaultRequestTimeout,
+		MaxRetries:         kDefaultMaxRetries,
+		RetryBaseDelay:     kDefaultRetryBaseDelay,
+		RetryMaxDelay:      kDefaultRetryMaxDelay,
+		BreakerFailures:    kDefaultBreakerFailures,
+		BreakerCooldown:    kDefaultBreakerCooldown,
+		StaleTTL:           kDefaultStaleTTL,
+		LogLevel:           LogLevelErrors,
+		credentials:        credentials,
+		baseURL:            strings.TrimRight(baseURL, "/"),
+		httpClient:         httpClient,
+		cache:              apiCache,
+	}
+	c.breaker.log = c.debugPrint
+	c.flights.log = c.debugPrint
+	return c
+}
+
+// Valid reports whether all the credentials required to authenticate are set.
+func (c Credentials) Valid() bool {
+	return c.ClientID != "" &&
+		c.Username != "" &&
+		c.Password != "" &&
+		c.SubscriptionKey != ""
+}
@@ -0,0 +1,64 @@
+package ahws
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a Client for a fake AHWS that hands out access tokens
+// and passes every other request to api.
+func newTestClient(t *testing.T, api http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, kAuthPath) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"token","expires_in":900,"refresh_token":"refresh","token_type":"bearer"}`))
+			return
+		}
+		api(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewClient(Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}, server.URL, nil, nil)
+	c.RetryBaseDelay = time.Millisecond
+	c.RetryMaxDelay = time.Millisecond * 5
+	t.Cleanup(c.Close)
+	return c
+}
+
+// get sends a GET for path straight to the fake AHWS, without an access
+// token or the cache.
+func get(t *testing.T, c *Client, path string) ([]byte, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.DoHTTPRequest(req)
+}
+
+func TestClientLogger(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	get(t, c, "/api/test")
+
+	var buf bytes.Buffer
+	c.Logger = log.New(&buf, "", 0)
+	get(t, c, "/api/test")
+	if !strings.Contains(buf.String(), "400") {
+		t.Errorf("got log %q, want the failed request logged", buf.String())
+	}
+
+	buf.Reset()
+	c.LogLevel = LogLevelNone
+	get(t, c, "/api/test")
+	if buf.Len() != 0 {
+		t.Errorf("got log %q at LogLevelNone", buf.String())
+	}
+}
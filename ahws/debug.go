@@ -0,0 +1,74 @@
+package ahws
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+)
+
+// Log levels for Client.LogLevel.
+const (
+	LogLevelNone = iota
+	LogLevelErrors
+	LogLevelVerbose
+	LogLevelTrace
+)
+
+func (c *Client) debugPrint(s any, logLevel int) {
+	if c.Logger != nil && c.LogLevel >= logLevel {
+		c.Logger.Println("DEBUG:", s)
+	}
+}
+
+func (c *Client) logError(err error) {
+	if err != nil && c.Logger != nil && c.LogLevel >= LogLevelErrors {
+		c.Logger.Println(err)
+	}
+}
+
+func (c *Client) logPrettyPrintJSON(data interface{}) {
+	if c.Logger != nil {
+		c.Logger.Println(prettyPrintJSON(data))
+	}
+}
+
+func prettyPrintJSON(data interface{}) string {
+	b, err := json.MarshalIndent(data, "", "    ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func (c *Client) marshalAndLogWithErrorOutput(request interface{}) (jsonRequestBody []byte, err error) {
+	pc, _, _, _ := runtime.Caller(1)
+	callerMethod := runtime.FuncForPC(pc).Name()
+
+	c.debugPrint("Marshing type:"+typeName(request)+" - from function:"+callerMethod, LogLevelVerbose)
+
+	jsonRequestBody, err = json.Marshal(request)
+	if err != nil {
+		c.debugPrint(err, LogLevelErrors)
+	}
+	return jsonRequestBody, err
+}
+
+func (c *Client) unMarshalAndLogWithErrorOutput(body []byte, request interface{}) (err error) {
+	pc, _, _, _ := runtime.Caller(1)
+	callerMethod := runtime.FuncForPC(pc).Name()
+	c.debugPrint("UnMarshing type:"+typeName(request)+" - from function:"+callerMethod, LogLevelVerbose)
+
+	err = json.Unmarshal(body, request)
+	if err != nil {
+		c.debugPrint(err, LogLevelErrors)
+	}
+	return err
+}
+
+func typeName(t any) (x string) {
+	n := reflect.TypeOf(t).Name()
+	if n == "" {
+		return reflect.TypeOf(t).Elem().String()
+	}
+	return n
+}
@@ -0,0 +1,43 @@
+package ahws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// APIError is returned when AHWS responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Endpoint   string
+	Response   ErrorResponse
+	// RetryAfter is set when AHWS sent a Retry-After header.
+	RetryAfter time.Duration
+}
+
+func newAPIError(statusCode int, endpoint string, body []byte) *APIError {
+	apiError := &APIError{
+		StatusCode: statusCode,
+		Endpoint:   endpoint,
+	}
+	// Not every error has a JSON body, the gateway returns plain text for
+	// some failures.
+	if err := json.Unmarshal(body, &apiError.Response); err != nil && len(body) > 0 {
+		apiError.Response.ErrorDesc = string(body)
+	}
+	return apiError
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("ahws: %s returned HTTP %d", e.Endpoint, e.StatusCode)
+	if e.Response.Error != "" {
+		msg += ": " + e.Response.Error
+	}
+	if e.Response.ErrorDesc != "" {
+		msg += ": " + e.Response.ErrorDesc
+	}
+	if e.Response.ErrorURI != "" {
+		msg += " (" + e.Response.ErrorURI + ")"
+	}
+	return msg
+}
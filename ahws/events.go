@@ -0,0 +1,97 @@
+package ahws
+
+import (
+	"context"
+	"time"
+)
+
+// DefiniteEventsResult is a definite event search along with how fresh the
+// events are.
+type DefiniteEventsResult struct {
+	Events []DefiniteEventSearchResponse
+	// Stale is set when AHWS hasn't been reached within the cache TTL, the
+	// events are the last good response and a refresh is in progress.
+	Stale     bool
+	FetchedAt time.Time
+}
+
+// GetBookingEventDetails searches definite events at a location within a
+// date range.
+func (c *Client) GetBookingEventDetails(ctx context.Context, definiteEventSearchRequest DefiniteEventSearchRequest) ([]DefiniteEventSearchResponse, error) {
+	result, err := c.SearchDefiniteEvents(ctx, definiteEventSearchRequest)
+	return result.Events, err
+}
+
+// SearchDefiniteEvents is GetBookingEventDetails, but also reports whether the
+// events were served stale from the cache.
+func (c *Client) SearchDefiniteEvents(ctx context.Context, definiteEventSearchRequest DefiniteEventSearchRequest) (DefiniteEventsResult, error) {
+	cacheKey := definiteEventsCacheKey(definiteEventSearchRequest)
+
+	if cached, found := c.getCachedResponse(cacheKey); found {
+		if cached.Stale() {
+			c.revalidate(cacheKey, func(ctx context.Context) error {
+				_, err := c.fetchDefiniteEvents(ctx, cacheKey, definiteEventSearchRequest)
+				return err
+			})
+		}
+		//LogPrettyPrintJSON(cachedEvents)
+		return DefiniteEventsResult{
+			Events:    cached.Value.([]DefiniteEventSearchResponse),
+			Stale:     cached.Stale(),
+			FetchedAt: cached.FetchedAt,
+		}, nil
+	}
+
+	definiteEventSearchResponse, err := c.fetchDefiniteEvents(ctx, cacheKey, definiteEventSearchRequest)
+	return DefiniteEventsResult{
+		Events:    definiteEventSearchResponse,
+		FetchedAt: time.Now(),
+	}, err
+}
+
+// RefreshDefiniteEvents runs the search against AHWS whether or not it is
+// cached, and caches the result.
+func (c *Client) RefreshDefiniteEvents(ctx context.Context, definiteEventSearchRequest DefiniteEventSearchRequest) ([]DefiniteEventSearchResponse, error) {
+	return c.fetchDefiniteEvents(ctx, definiteEventsCacheKey(definiteEventSearchRequest), definiteEventSearchRequest)
+}
+
+func definiteEventsCacheKey(definiteEventSearchRequest DefiniteEventSearchRequest) string {
+	return requestCacheKey("BookingEventsDetailsWithInDateRange", definiteEventSearchRequest)
+}
+
+// fetchDefiniteEvents searches AHWS, concurrent searches for the same
+// cacheKey share a single request.
+func (c *Client) fetchDefiniteEvents(ctx context.Context, cacheKey string, definiteEventSearchRequest DefiniteEventSearchRequest) ([]DefiniteEventSearchResponse, error) {
+	events, err := c.flights.do(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return c.searchDefiniteEvents(ctx, cacheKey, definiteEventSearchRequest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events.([]DefiniteEventSearchResponse), nil
+}
+
+func (c *Client) searchDefiniteEvents(ctx context.Context, cacheKey string, definiteEventSearchRequest DefiniteEventSearchRequest) ([]DefiniteEventSearchResponse, error) {
+	var definiteEventSearchResponse []DefiniteEventSearchResponse
+
+	jsonRequestBody, err := c.marshalAndLogWithErrorOutput(definiteEventSearchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.httpPostJSON(ctx, kDefiniteEventSearchPath, jsonRequestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &definiteEventSearchResponse)
+	if err == nil {
+		// A day without events is cached too, or every screen showing it
+		// would search AHWS each poll.
+		if definiteEventSearchResponse == nil {
+			definiteEventSearchResponse = []DefiniteEventSearchResponse{}
+		}
+		c.setCachedResponse(cacheKey, definiteEventSearchResponse)
+	}
+	return definiteEventSearchResponse, err
+}
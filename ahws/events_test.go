@@ -0,0 +1,32 @@
+package ahws
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSearchDefiniteEventsCachesEmptyDays(t *testing.T) {
+	for _, body := range []string{"[]", "null"} {
+		var calls int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Write([]byte(body))
+		})
+
+		for i := 0; i < 3; i++ {
+			result, err := c.SearchDefiniteEvents(context.Background(), DefiniteEventSearchRequest{
+				BookingEventDateTimeBegin: "2026-10-18",
+				BookingEventDateTimeEnd:   "2026-10-19",
+				LocationId:                "L1",
+			})
+			if err != nil || len(result.Events) != 0 {
+				t.Fatalf("%s: got %v, %v, want no events", body, result.Events, err)
+			}
+		}
+		if calls := atomic.LoadInt32(&calls); calls != 1 {
+			t.Errorf("%s: got %d upstream searches, want the empty day cached", body, calls)
+		}
+	}
+}
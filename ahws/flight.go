@@ -0,0 +1,66 @@
+package ahws
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall is an in-flight upstream request shared by every caller that
+// asked for the same key while it was running.
+type flightCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// flightGroup collapses concurrent calls with the same key into one.
+type flightGroup struct {
+	// log is the client's debugPrint, nil in tests.
+	log func(s any, logLevel int)
+
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do runs fn once for all concurrent callers using key and hands each of them
+// the result. fn doesn't get the caller's context, the first caller going
+// away shouldn't fail the request for everybody else, instead each caller
+// stops waiting when its own ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+	call, inFlight := g.calls[key]
+	if !inFlight {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+	}
+	g.mu.Unlock()
+
+	if inFlight {
+		g.debugPrint("joining in-flight request("+key+")", LogLevelVerbose)
+	} else {
+		go func() {
+			call.value, call.err = fn(context.Background())
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) debugPrint(s any, logLevel int) {
+	if g.log != nil {
+		g.log(s, logLevel)
+	}
+}
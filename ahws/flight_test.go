@@ -0,0 +1,83 @@
+package ahws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentMissesShareOneRequest(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// Long enough for every caller to join the request in flight.
+		time.Sleep(time.Millisecond * 200)
+		w.Write([]byte(`[{"Id":"L1","Name":"Hotel"}]`))
+	})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locations, err := c.GetLocationsByID(context.Background())
+			if err != nil || len(locations) != 1 || locations[0].Id != "L1" {
+				t.Errorf("got %v, %v, want location L1", locations, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("got %d upstream requests for %d concurrent misses, want 1", calls, callers)
+	}
+}
+
+func TestFlightCallerGivesUp(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	var calls int32
+	fn := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan error)
+	go func() {
+		_, err := g.do(ctx, "key", fn)
+		gaveUp <- err
+	}()
+	for inFlight := false; !inFlight; {
+		time.Sleep(time.Millisecond)
+		g.mu.Lock()
+		_, inFlight = g.calls["key"]
+		g.mu.Unlock()
+	}
+
+	waited := make(chan any)
+	go func() {
+		value, _ := g.do(context.Background(), "key", fn)
+		waited <- value
+	}()
+
+	// The first caller going away mustn't fail the call for the other.
+	cancel()
+	if err := <-gaveUp; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+	if value := <-waited; value != "value" {
+		t.Errorf("got %v for the caller still waiting, want value", value)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("fn ran %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,167 @@
+package ahws
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// GetFunctionRoomGroup returns the function room groups at the given
+// locations. AHWS searches function room groups by location, not group ID.
+func (c *Client) GetFunctionRoomGroup(ctx context.Context, locationIDs []string) ([]FunctionRoomGroupsResponse, error) {
+	if len(locationIDs) == 0 {
+		return nil, errors.New("empty array")
+	}
+
+	var IDsToQuery []string
+	var staleIDs []string
+	var cachedFunctionRoomGroups []FunctionRoomGroupsResponse
+
+	for _, key := range locationIDs {
+		cached, found := c.getCachedResponse("FunctionRoomGroupsAtLocation:" + key)
+		if found {
+			cachedFunctionRoomGroups = append(cachedFunctionRoomGroups, cached.Value.([]FunctionRoomGroupsResponse)...)
+			if cached.Stale() {
+				staleIDs = append(staleIDs, key)
+			}
+		} else {
+			IDsToQuery = append(IDsToQuery, key)
+		}
+	}
+
+	for _, key := range staleIDs {
+		key := key
+		c.revalidate("FunctionRoomGroupsAtLocation:"+key, func(ctx context.Context) error {
+			_, err := c.fetchFunctionRoomGroups(ctx, []string{key})
+			return err
+		})
+	}
+
+	if len(IDsToQuery) == 0 {
+		return cachedFunctionRoomGroups, nil
+	}
+
+	functionRoomGroupsResponse, err := c.fetchFunctionRoomGroups(ctx, IDsToQuery)
+	if err != nil {
+		return nil, err
+	}
+	return append(functionRoomGroupsResponse, cachedFunctionRoomGroups...), nil
+}
+
+// RefreshFunctionRoomGroups fetches the function room groups at the given
+// locations from AHWS whether or not they are cached, and caches the result.
+func (c *Client) RefreshFunctionRoomGroups(ctx context.Context, locationIDs []string) ([]FunctionRoomGroupsResponse, error) {
+	if len(locationIDs) == 0 {
+		return nil, errors.New("empty array")
+	}
+	return c.fetchFunctionRoomGroups(ctx, locationIDs)
+}
+
+func (c *Client) fetchFunctionRoomGroups(ctx context.Context, locationIDs []string) ([]FunctionRoomGroupsResponse, error) {
+	sortedIDs := append([]string(nil), locationIDs...)
+	sort.Strings(sortedIDs)
+
+	groups, err := c.flights.do(ctx, "FunctionRoomGroups:"+strings.Join(sortedIDs, ","), func(ctx context.Context) (any, error) {
+		return c.searchFunctionRoomGroups(ctx, locationIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups.([]FunctionRoomGroupsResponse), nil
+}
+
+func (c *Client) searchFunctionRoomGroups(ctx context.Context, locationIDs []string) ([]FunctionRoomGroupsResponse, error) {
+	functionRoomGroupsRequest := FunctionRoomGroupRequest{
+		locationIDs,
+	}
+
+	jsonRequestBody, err := c.marshalAndLogWithErrorOutput(functionRoomGroupsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var functionRoomGroupsResponse []FunctionRoomGroupsResponse
+
+	body, err := c.httpPostJSON(ctx, kFunctionRoomGroupSearchPath, jsonRequestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &functionRoomGroupsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached per location, including locations without any groups.
+	for _, locationID := range locationIDs {
+		groups := []FunctionRoomGroupsResponse{}
+		for _, group := range functionRoomGroupsResponse {
+			if strings.EqualFold(group.LocationId, locationID) {
+				groups = append(groups, group)
+			}
+		}
+		c.setCachedResponse("FunctionRoomGroupsAtLocation:"+locationID, groups)
+	}
+	return functionRoomGroupsResponse, nil
+}
+
+// GetFunctionRooms returns the function room export for the requested
+// locations.
+func (c *Client) GetFunctionRooms(ctx context.Context, functionRoomRequest FunctionRoomRequest) ([]LocationFunctionRoomsResponse, error) {
+	jsonRequestBody, err := c.marshalAndLogWithErrorOutput(functionRoomRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var functionRoomsResponse []LocationFunctionRoomsResponse
+
+	body, err := c.httpPostJSON(ctx, kFunctionRoomsExportPath, jsonRequestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &functionRoomsResponse)
+	if c.LogLevel >= LogLevelTrace {
+		c.logPrettyPrintJSON(functionRoomsResponse)
+	}
+	return functionRoomsResponse, err
+}
+
+// GetFunctionRoomsAtLocation returns the function room export for one
+// location, cached.
+func (c *Client) GetFunctionRoomsAtLocation(ctx context.Context, locationID string) ([]LocationFunctionRoomsResponse, error) {
+	cacheKey := "FunctionRoomsAtLocation:" + locationID
+	if cached, found := c.getCachedResponse(cacheKey); found {
+		if cached.Stale() {
+			c.revalidate(cacheKey, func(ctx context.Context) error {
+				_, err := c.fetchFunctionRoomsAtLocation(ctx, locationID)
+				return err
+			})
+		}
+		return cached.Value.([]LocationFunctionRoomsResponse), nil
+	}
+	return c.fetchFunctionRoomsAtLocation(ctx, locationID)
+}
+
+// RefreshFunctionRoomsAtLocation fetches the function room export for one
+// location from AHWS whether or not it is cached, and caches the result.
+func (c *Client) RefreshFunctionRoomsAtLocation(ctx context.Context, locationID string) ([]LocationFunctionRoomsResponse, error) {
+	return c.fetchFunctionRoomsAtLocation(ctx, locationID)
+}
+
+func (c *Client) fetchFunctionRoomsAtLocation(ctx context.Context, locationID string) ([]LocationFunctionRoomsResponse, error) {
+	cacheKey := "FunctionRoomsAtLocation:" + locationID
+	rooms, err := c.flights.do(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		rooms, err := c.GetFunctionRooms(ctx, FunctionRoomRequest{LocationIDs: []string{locationID}})
+		if err != nil {
+			return nil, err
+		}
+		c.setCachedResponse(cacheKey, rooms)
+		return rooms, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rooms.([]LocationFunctionRoomsResponse), nil
+}
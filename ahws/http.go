@@ -0,0 +1,177 @@
+package ahws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func (c *Client) httpGet(ctx context.Context, path string) ([]byte, error) {
+	return c.httpAPIRequest(ctx, "GET", path, nil)
+}
+
+func (c *Client) httpPostJSON(ctx context.Context, path string, json []byte) ([]byte, error) {
+	return c.httpAPIRequest(ctx, "POST", path, json)
+}
+
+// httpAPIRequest sends a request to path. If AHWS rejects the access token
+// with a 401 the token is invalidated and the request is retried once.
+func (c *Client) httpAPIRequest(ctx context.Context, requestType string, path string, json []byte) ([]byte, error) {
+	req, err := c.newHTTPRequest(ctx, requestType, path, json)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.DoHTTPRequest(req)
+
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusUnauthorized && strings.HasPrefix(path, kAPIPath) {
+		c.debugPrint("access token rejected, retrying with a new token - "+path, LogLevelErrors)
+		c.InvalidateAuthToken(strings.TrimPrefix(req.Header.Get("Authorization"), "OAuth "))
+
+		req, err = c.newHTTPRequest(ctx, requestType, path, json)
+		if err != nil {
+			return nil, err
+		}
+		body, err = c.DoHTTPRequest(req)
+	}
+	return body, err
+}
+
+func (c *Client) newHTTPRequest(ctx context.Context, requestType string, path string, json []byte) (*http.Request, error) {
+	URI := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, requestType, URI, bytes.NewReader(json))
+	if err != nil {
+		c.debugPrint(err, LogLevelErrors)
+		return nil, err
+	}
+
+	req.Header.Set("Ocp-Apim-Subscription-Key", c.credentials.SubscriptionKey)
+
+	if json != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if strings.HasPrefix(path, kAPIPath) {
+		c.debugPrint(URI, LogLevelVerbose)
+		authToken, err := c.GetAuthToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "OAuth "+authToken)
+	}
+
+	return req, nil
+}
+
+func (c *Client) httpDo(ctx context.Context, req *http.Request, f func(*http.Response, error) error) error {
+	// Run the HTTP request in a goroutine and pass the response to f.
+	ch := make(chan error, 1)
+	req = req.WithContext(ctx)
+	go func() { ch <- f(c.httpClient.Do(req)) }()
+	select {
+	case <-ctx.Done():
+		<-ch // Wait for f to return.
+		return ctx.Err()
+	case err := <-ch:
+		return err
+	}
+}
+
+// DoHTTPRequest sends req and returns the response body. Non-2xx responses
+// return an *APIError. Network errors, 429s and 5xxs are retried with backoff
+// up to MaxRetries times, each attempt limited to RequestTimeout.
+func (c *Client) DoHTTPRequest(req *http.Request) (body []byte, err error) {
+	c.debugPrint(req.URL, LogLevelVerbose)
+
+	for attempt := 0; ; attempt++ {
+		if !c.breaker.allow(c.BreakerCooldown) {
+			return nil, ErrCircuitOpen
+		}
+
+		body, err = c.doHTTPAttempt(req)
+
+		if req.Context().Err() != nil {
+			// The caller gave up, that says nothing about AHWS.
+			c.breaker.release()
+			return body, err
+		}
+		unavailable := IsUnavailable(err)
+		c.breaker.record(!unavailable, c.BreakerFailures)
+		if !unavailable || attempt >= c.MaxRetries {
+			return body, err
+		}
+
+		delay := c.retryDelay(attempt, err)
+		if delay < 0 {
+			return body, err
+		}
+		c.debugPrint("retrying "+req.URL.Path+" in "+delay.String()+" after: "+err.Error(), LogLevelErrors)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return body, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) doHTTPAttempt(req *http.Request) (body []byte, err error) {
+	ctx := req.Context()
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+
+	req = req.Clone(ctx)
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.httpDo(ctx, req, func(resp *http.Response, err error) error {
+		if err != nil {
+			c.debugPrint(err, LogLevelErrors)
+			return err
+		} else {
+			pc, _, _, _ := runtime.Caller(1)
+			callerMethod := runtime.FuncForPC(pc).Name()
+			c.debugPrint("HTTP code:"+resp.Status+" - in function:"+callerMethod, LogLevelVerbose)
+			defer resp.Body.Close()
+			body, err = io.ReadAll(resp.Body)
+			if err != nil {
+				c.debugPrint(err, LogLevelErrors)
+				return err
+			}
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				c.debugPrint("HTTP code:"+resp.Status+" - in function:"+callerMethod, LogLevelErrors)
+				apiError := newAPIError(resp.StatusCode, req.URL.Path, body)
+				apiError.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+				err = apiError
+			}
+			if c.Logger != nil && c.LogLevel == LogLevelTrace {
+				c.Logger.Print("request header")
+				c.logPrettyPrintJSON(req.Header)
+
+				c.Logger.Print("request body:")
+				c.logPrettyPrintJSON(req.Body)
+
+				c.Logger.Print("response header")
+				c.logPrettyPrintJSON(resp.Header)
+
+				c.Logger.Print("response body:")
+				c.logPrettyPrintJSON(resp.Body)
+			}
+		}
+		return err
+	})
+	return body, err
+}
@@ -0,0 +1,60 @@
+package ahws
+
+import (
+	"context"
+)
+
+// GetLocationsByID returns every location the account has access to.
+func (c *Client) GetLocationsByID(ctx context.Context) ([]LocationResponse, error) {
+	return c.getLocations(ctx, "LocationsByID", kLocationsByID)
+}
+
+// GetLocationsByExternalID returns every location the account has access to,
+// keyed by the PMS location ID.
+func (c *Client) GetLocationsByExternalID(ctx context.Context) ([]LocationResponse, error) {
+	return c.getLocations(ctx, "LocationsByExternalID", kLocationsByExternalID)
+}
+
+// RefreshLocationsByID fetches GetLocationsByID from AHWS whether or not it is
+// cached, and caches the result.
+func (c *Client) RefreshLocationsByID(ctx context.Context) ([]LocationResponse, error) {
+	return c.fetchLocations(ctx, "LocationsByID", kLocationsByID)
+}
+
+func (c *Client) getLocations(ctx context.Context, cacheKey string, path string) ([]LocationResponse, error) {
+	if cached, found := c.getCachedResponse(cacheKey); found {
+		if cached.Stale() {
+			c.revalidate(cacheKey, func(ctx context.Context) error {
+				_, err := c.fetchLocations(ctx, cacheKey, path)
+				return err
+			})
+		}
+		//LogPrettyPrintJSON(cached)
+		return cached.Value.([]LocationResponse), nil
+	}
+	return c.fetchLocations(ctx, cacheKey, path)
+}
+
+func (c *Client) fetchLocations(ctx context.Context, cacheKey string, path string) ([]LocationResponse, error) {
+	locations, err := c.flights.do(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		return c.requestLocations(ctx, cacheKey, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locations.([]LocationResponse), nil
+}
+
+func (c *Client) requestLocations(ctx context.Context, cacheKey string, path string) ([]LocationResponse, error) {
+	var locationResponse []LocationResponse
+	body, err := c.httpGet(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.unMarshalAndLogWithErrorOutput(body, &locationResponse)
+	if len(locationResponse) > 0 {
+		c.setCachedResponse(cacheKey, locationResponse)
+	}
+	return locationResponse, err
+}
@@ -0,0 +1,74 @@
+package ahws
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	kDefaultRequestTimeout  time.Duration = time.Second * 10
+	kDefaultMaxRetries      int           = 3
+	kDefaultRetryBaseDelay  time.Duration = time.Millisecond * 250
+	kDefaultRetryMaxDelay   time.Duration = time.Second * 5
+	kMaxRetryAfter          time.Duration = time.Minute
+	kDefaultBreakerFailures int           = 5
+	kDefaultBreakerCooldown time.Duration = time.Second * 30
+)
+
+// IsUnavailable reports whether err means AHWS couldn't be reached or is
+// failing, as opposed to rejecting the request.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode == http.StatusTooManyRequests || apiError.StatusCode >= 500
+	}
+	// Transport errors and timeouts.
+	return !errors.Is(err, context.Canceled)
+}
+
+// retryDelay returns how long to wait before retrying attempt, using
+// exponential backoff with full jitter unless AHWS asked for longer with
+// Retry-After. A negative delay means don't retry.
+func (c *Client) retryDelay(attempt int, err error) time.Duration {
+	backoff := c.RetryBaseDelay << uint(attempt)
+	if backoff <= 0 || backoff > c.RetryMaxDelay {
+		backoff = c.RetryMaxDelay
+	}
+	delay := time.Duration(rand.Int63n(int64(backoff) + 1))
+
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.RetryAfter > 0 {
+		if apiError.RetryAfter > kMaxRetryAfter {
+			return -1
+		}
+		if apiError.RetryAfter > delay {
+			delay = apiError.RetryAfter
+		}
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header in either delay-seconds or
+// HTTP-date form.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
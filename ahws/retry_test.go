@@ -0,0 +1,116 @@
+package ahws
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryUnavailable(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	body, err := get(t, c, "/ping")
+	if err != nil {
+		t.Fatalf("got error %v, want the retry to succeed", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("got body %q, want %q", body, "ok")
+	}
+	if calls != 2 {
+		t.Errorf("got %d requests, want 2", calls)
+	}
+}
+
+func TestNoRetryForRejectedRequest(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	_, err := get(t, c, "/ping")
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got error %v, want an APIError with status 400", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+}
+
+func TestNoRetryForLongRetryAfter(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	start := time.Now()
+	_, err := get(t, c, "/ping")
+	if !IsUnavailable(err) {
+		t.Fatalf("got error %v, want AHWS unavailable", err)
+	}
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.RetryAfter != time.Minute*2 {
+		t.Errorf("got error %#v, want RetryAfter 2m", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want no wait before giving up", elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := NewClient(Credentials{}, "", nil, nil)
+	c.RetryBaseDelay = time.Millisecond * 100
+	c.RetryMaxDelay = time.Second
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"first attempt", 0, errors.New("timeout"), 0, time.Millisecond * 100},
+		{"backoff doubles", 2, errors.New("timeout"), 0, time.Millisecond * 400},
+		{"capped at RetryMaxDelay", 10, errors.New("timeout"), 0, time.Second},
+		{"Retry-After is honoured", 0, &APIError{StatusCode: 429, RetryAfter: time.Second * 30}, time.Second * 30, time.Second * 30},
+		{"Retry-After over a minute", 0, &APIError{StatusCode: 503, RetryAfter: time.Minute * 2}, -1, -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				delay := c.retryDelay(test.attempt, test.err)
+				if delay < test.min || delay > test.max {
+					t.Fatalf("got %v, want between %v and %v", delay, test.min, test.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("30"); got != time.Second*30 {
+		t.Errorf("parseRetryAfter(30) = %v, want 30s", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("parseRetryAfter(\"\") = %v, want 0", got)
+	}
+	date := time.Now().Add(time.Minute * 5).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < time.Minute*4 || got > time.Minute*5 {
+		t.Errorf("parseRetryAfter(%s) = %v, want about 5m", date, got)
+	}
+}
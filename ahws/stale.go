@@ -0,0 +1,73 @@
+package ahws
+
+import (
+	"context"
+	"time"
+)
+
+// kDefaultStaleTTL is how long a response is kept after it stops being fresh,
+// to be served while it is refreshed in the background or while AHWS is
+// unavailable.
+const kDefaultStaleTTL time.Duration = time.Hour * 24
+
+// CachedResponse is how responses are stored in the cache. Value is returned
+// as is until FreshUntil, and after that marked stale until the cache entry
+// expires StaleTTL later.
+type CachedResponse struct {
+	Value      any
+	FetchedAt  time.Time
+	FreshUntil time.Time
+}
+
+// Stale reports whether the response is past its fresh TTL.
+func (r CachedResponse) Stale() bool {
+	return time.Now().After(r.FreshUntil)
+}
+
+func (c *Client) getCachedResponse(cacheKey string) (CachedResponse, bool) {
+	if c.CacheLevel != CacheLevelAll {
+		return CachedResponse{}, false
+	}
+	cached, expiresAt, found := c.cache.GetWithExpiration(cacheKey)
+	if !found {
+		return CachedResponse{}, false
+	}
+	response, ok := cached.(CachedResponse)
+	if !ok {
+		// Written by an older version, before responses were wrapped.
+		return CachedResponse{}, false
+	}
+	if response.Stale() {
+		c.debugPrint("hit stale cache("+cacheKey+") - fetched at: "+response.FetchedAt.String(), LogLevelVerbose)
+	} else {
+		c.debugPrint("hit cache("+cacheKey+") - expires at: "+expiresAt.String(), LogLevelVerbose)
+	}
+	return response, true
+}
+
+func (c *Client) setCachedResponse(cacheKey string, value any) {
+	if c.CacheLevel != CacheLevelAll {
+		return
+	}
+	now := time.Now()
+	c.cache.Set(cacheKey, CachedResponse{
+		Value:      value,
+		FetchedAt:  now,
+		FreshUntil: now.Add(kTTL),
+	}, kTTL+c.StaleTTL)
+}
+
+// revalidate runs refresh in the background to replace a stale cache entry.
+// Only one refresh per cacheKey runs at a time.
+func (c *Client) revalidate(cacheKey string, refresh func(ctx context.Context) error) {
+	if _, running := c.revalidating.LoadOrStore(cacheKey, true); running {
+		return
+	}
+	go func() {
+		defer c.revalidating.Delete(cacheKey)
+		c.debugPrint("revalidating "+cacheKey, LogLevelVerbose)
+		if err := refresh(context.Background()); err != nil {
+			c.debugPrint("revalidating "+cacheKey+" failed, still serving stale data: "+err.Error(), LogLevelErrors)
+		}
+	}()
+}
@@ -0,0 +1,93 @@
+package ahws
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var calls, failing int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n > 1 {
+			<-release
+		}
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n == 1 {
+			w.Write([]byte(`[{"Name":"Original"}]`))
+			return
+		}
+		w.Write([]byte(`[{"Name":"Updated"}]`))
+	})
+
+	request := DefiniteEventSearchRequest{LocationId: "L1", BookingEventDateTimeBegin: "2026-10-18", BookingEventDateTimeEnd: "2026-10-19"}
+	search := func() DefiniteEventsResult {
+		t.Helper()
+		result, err := c.SearchDefiniteEvents(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if result := search(); result.Stale || result.Events[0].Name != "Original" {
+		t.Fatalf("got %+v, want the fresh original events", result)
+	}
+
+	// Age the cached response past its fresh TTL.
+	key := definiteEventsCacheKey(request)
+	cached, _ := c.getCachedResponse(key)
+	cached.FreshUntil = time.Now().Add(-time.Second)
+	c.cache.Set(key, cached, time.Hour)
+
+	atomic.StoreInt32(&failing, 1)
+	for i := 0; i < 5; i++ {
+		if result := search(); !result.Stale || result.Events[0].Name != "Original" {
+			t.Fatalf("got %+v, want the original events marked stale", result)
+		}
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 2 })
+	time.Sleep(time.Millisecond * 20)
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("got %d upstream calls, want one background revalidation", calls)
+	}
+
+	// The revalidation fails, and the stale copy is still served.
+	close(release)
+	waitFor(t, func() bool {
+		_, running := c.revalidating.Load(key)
+		return !running
+	})
+	if result := search(); !result.Stale || result.Events[0].Name != "Original" {
+		t.Fatalf("got %+v after upstream failed, want the stale events", result)
+	}
+
+	// Once AHWS is back the next revalidation replaces them.
+	atomic.StoreInt32(&failing, 0)
+	waitFor(t, func() bool {
+		_, running := c.revalidating.Load(key)
+		return !running
+	})
+	search()
+	waitFor(t, func() bool {
+		result := search()
+		return !result.Stale && result.Events[0].Name == "Updated"
+	})
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
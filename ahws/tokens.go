@@ -0,0 +1,162 @@
+package ahws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// kRefreshTokenTTL is a little under the 72 hours AHWS honours refresh
+	// tokens for, the response doesn't include it.
+	kRefreshTokenTTL time.Duration = time.Hour * 71
+	// kDefaultTokenRefreshBefore is how long before expiry the access token is
+	// refreshed in the background.
+	kDefaultTokenRefreshBefore time.Duration = time.Minute * 2
+)
+
+// GetAuthToken returns a valid access token, refreshing or re-authenticating
+// as required. Concurrent callers share a single upstream request.
+func (c *Client) GetAuthToken(ctx context.Context) (string, error) {
+	if c.CacheLevel == CacheLevelNone {
+		response, err := c.Authenticate(ctx)
+		if err == nil && response.AuthToken == "" {
+			err = errors.New("failed to obtain auth token")
+		}
+		return response.AuthToken, err
+	}
+
+	if cached, found := c.cache.Get("AuthTokenResponse"); found {
+		response := cached.(AuthTokenResponse)
+		if response.AuthToken != "" && time.Now().Unix() < response.ExpiresAt {
+			c.debugPrint("AccessToken.expiresAt:"+time.Unix(response.ExpiresAt, 0).String(), LogLevelVerbose)
+			// Tokens loaded from a saved cache won't have a refresh scheduled yet.
+			c.scheduleTokenRefresh(response)
+			return response.AuthToken, nil
+		}
+	}
+
+	return c.fetchAuthToken(ctx)
+}
+
+// InvalidateAuthToken drops the cached access token if it is still token, so
+// the next call to GetAuthToken requests a new one.
+func (c *Client) InvalidateAuthToken(token string) {
+	if cached, found := c.cache.Get("AuthTokenResponse"); found {
+		if cached.(AuthTokenResponse).AuthToken == token {
+			c.cache.Delete("AuthTokenResponse")
+		}
+	}
+}
+
+// Close stops the background token refresh.
+func (c *Client) Close() {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.tokenTimer != nil {
+		c.tokenTimer.Stop()
+		c.tokenTimer = nil
+	}
+}
+
+func (c *Client) fetchAuthToken(ctx context.Context) (string, error) {
+	token, err := c.flights.do(ctx, "AuthToken", func(ctx context.Context) (any, error) {
+		var response AuthTokenResponse
+		var err error
+		if cached, found := c.cache.Get("RefreshAccessToken"); found && cached.(string) != "" {
+			response, err = c.RefreshAccessToken(ctx, cached.(string))
+			if err != nil {
+				c.debugPrint("refreshing access token failed, re-authenticating: "+err.Error(), LogLevelErrors)
+				c.cache.Delete("RefreshAccessToken")
+			}
+		}
+		if response.AuthToken == "" {
+			response, err = c.Authenticate(ctx)
+		}
+		if err == nil && response.AuthToken == "" {
+			err = errors.New("failed to obtain auth token")
+		}
+		if err != nil {
+			// Replace with alert and retry
+			c.debugPrint("Failed to obtain auth token! "+err.Error(), LogLevelErrors)
+			return "", err
+		}
+		return response.AuthToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+func (c *Client) cacheAuthTokenResponse(response AuthTokenResponse) {
+	if c.CacheLevel == CacheLevelNone {
+		return
+	}
+	c.cache.Set("AuthTokenResponse", response, time.Until(time.Unix(response.ExpiresAt, 0)))
+	if response.RefreshToken != "" {
+		c.cache.Set("RefreshAccessToken", response.RefreshToken, kRefreshTokenTTL)
+	}
+	c.scheduleTokenRefresh(response)
+}
+
+// scheduleTokenRefresh arranges for the access token in response to be
+// refreshed in the background shortly before it expires.
+func (c *Client) scheduleTokenRefresh(response AuthTokenResponse) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	expiresAt := response.ExpiresAt
+	if c.tokenTimer != nil && c.tokenTimerFor == expiresAt {
+		return
+	}
+	if c.tokenTimer != nil {
+		c.tokenTimer.Stop()
+	}
+
+	lifetime := time.Until(time.Unix(expiresAt, 0))
+	refreshBefore := c.TokenRefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = kDefaultTokenRefreshBefore
+	}
+	// Short lived tokens are refreshed once 80% of their lifetime has passed.
+	if refreshBefore > lifetime/5 {
+		refreshBefore = lifetime / 5
+	}
+
+	c.tokenTimerFor = expiresAt
+	c.tokenTimer = time.AfterFunc(lifetime-refreshBefore, func() {
+		c.refreshAuthToken(response.AuthToken)
+	})
+}
+
+// refreshAuthToken replaces token before it expires. With a shared cache every
+// replica schedules a refresh, so if another one has already replaced token
+// that one is used instead. Refreshing again would overwrite it, and the
+// refresh token the others are relying on.
+func (c *Client) refreshAuthToken(token string) {
+	if cached, found := c.cache.Get("AuthTokenResponse"); found {
+		response := cached.(AuthTokenResponse)
+		if response.AuthToken != "" && response.AuthToken != token && time.Now().Unix() < response.ExpiresAt {
+			c.debugPrint("access token already refreshed", LogLevelVerbose)
+			c.scheduleTokenRefresh(response)
+			return
+		}
+	}
+
+	c.debugPrint("refreshing access token before expiry", LogLevelVerbose)
+	if _, err := c.fetchAuthToken(context.Background()); err != nil {
+		c.logError(err)
+	}
+}
+
+// expiresAt converts an expires_in value in seconds to a unix timestamp.
+func (c *Client) expiresAt(expiresIn json.Number) int64 {
+	seconds, err := expiresIn.Int64()
+	if err != nil || seconds <= 0 {
+		c.debugPrint("missing expires_in, assuming "+kTTL.String(), LogLevelVerbose)
+		seconds = int64(kTTL / time.Second)
+	}
+	return time.Now().Add(time.Duration(seconds) * time.Second).Unix()
+}
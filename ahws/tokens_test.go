@@ -0,0 +1,178 @@
+package ahws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"example.com/m/v2/cachestore"
+)
+
+func TestReplicasShareRefreshedToken(t *testing.T) {
+	var authentications, refreshes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int32
+		switch r.URL.Path {
+		case kAccessTokenPath:
+			n = atomic.AddInt32(&authentications, 1)
+		case kRefreshAccessTokenPath:
+			n = atomic.AddInt32(&refreshes, 1) + 100
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":900,"refresh_token":"refresh%d"}`, n, n)
+	}))
+	defer server.Close()
+
+	// Two replicas sharing a cache.
+	shared := cachestore.NewMemory(time.Minute, time.Minute)
+	credentials := Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}
+	a := NewClient(credentials, server.URL, nil, shared)
+	b := NewClient(credentials, server.URL, nil, shared)
+	defer a.Close()
+	defer b.Close()
+
+	ctx := context.Background()
+	token, err := a.GetAuthToken(ctx)
+	if err != nil || token != "token1" {
+		t.Fatalf("got %q, %v, want token1", token, err)
+	}
+	if token, _ := b.GetAuthToken(ctx); token != "token1" {
+		t.Fatalf("replica b got %q, want the shared token1", token)
+	}
+
+	// Both replicas' timers fire for token1.
+	a.refreshAuthToken("token1")
+	b.refreshAuthToken("token1")
+
+	if refreshes != 1 {
+		t.Errorf("got %d refreshes, want 1", refreshes)
+	}
+	if authentications != 1 {
+		t.Errorf("got %d authentications, want 1", authentications)
+	}
+	for name, c := range map[string]*Client{"a": a, "b": b} {
+		if token, _ := c.GetAuthToken(ctx); token != "token101" {
+			t.Errorf("replica %s got %q, want the refreshed token101", name, token)
+		}
+	}
+	if refreshToken, _ := shared.Get("RefreshAccessToken"); refreshToken != "refresh101" {
+		t.Errorf("got refresh token %v, want refresh101", refreshToken)
+	}
+}
+
+// tokenServer is a fake AHWS handing out token1, token2... and passing other
+// requests to api.
+func tokenServer(t *testing.T, expiresIn string, api http.HandlerFunc) (*httptest.Server, *int32, *int32) {
+	t.Helper()
+	var authentications, refreshes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int32
+		switch r.URL.Path {
+		case kAccessTokenPath:
+			n = atomic.AddInt32(&authentications, 1)
+		case kRefreshAccessTokenPath:
+			n = atomic.AddInt32(&refreshes, 1) + 100
+		default:
+			api(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":%s,"refresh_token":"refresh%d"}`, n, expiresIn, n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &authentications, &refreshes
+}
+
+func TestUnauthorizedRetriesOnceWithNewToken(t *testing.T) {
+	var calls, rejected int32
+	server, authentications, refreshes := tokenServer(t, "900", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") == "OAuth token1" || atomic.LoadInt32(&rejected) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	c := NewClient(Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}, server.URL, nil, nil)
+	defer c.Close()
+
+	// token1 is rejected, and replaced using its refresh token.
+	if _, err := c.httpGet(context.Background(), kLocationsByID); err != nil {
+		t.Fatal(err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+	if authentications, refreshes := atomic.LoadInt32(authentications), atomic.LoadInt32(refreshes); authentications != 1 || refreshes != 1 {
+		t.Errorf("got %d authentications and %d refreshes, want 1 of each", authentications, refreshes)
+	}
+	if token, _ := c.GetAuthToken(context.Background()); token != "token101" {
+		t.Errorf("got %q, want token101 cached", token)
+	}
+
+	// A second 401 is returned rather than retried again.
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&rejected, 1)
+	_, err := c.httpGet(context.Background(), kLocationsByID)
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want a 401 APIError", err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("got %d calls, want the request retried only once", calls)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	c := NewClient(Credentials{}, "", nil, nil)
+	now := time.Now().Unix()
+	tests := []struct {
+		expiresIn json.Number
+		want      int64
+	}{
+		{"900", 900},
+		{"60", 60},
+		{"", int64(kTTL / time.Second)},
+		{"0", int64(kTTL / time.Second)},
+		{"soon", int64(kTTL / time.Second)},
+	}
+	for _, test := range tests {
+		if got := c.expiresAt(test.expiresIn) - now; got < test.want || got > test.want+1 {
+			t.Errorf("expires_in %q: expires in %ds, want %ds", test.expiresIn, got, test.want)
+		}
+	}
+}
+
+func TestTokenRefreshedBeforeExpiry(t *testing.T) {
+	server, _, refreshes := tokenServer(t, "3", func(w http.ResponseWriter, r *http.Request) {})
+	c := NewClient(Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}, server.URL, nil, nil)
+	defer c.Close()
+
+	if _, err := c.GetAuthToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cached, _ := c.cache.Get("AuthTokenResponse")
+	expiresAt := time.Unix(cached.(AuthTokenResponse).ExpiresAt, 0)
+
+	// A 3 second token is refreshed once 80% of its lifetime has passed.
+	time.Sleep(time.Until(expiresAt) / 2)
+	if n := atomic.LoadInt32(refreshes); n != 0 {
+		t.Fatalf("refreshed %d times halfway through the token's lifetime", n)
+	}
+	for atomic.LoadInt32(refreshes) == 0 && time.Now().Before(expiresAt) {
+		time.Sleep(time.Millisecond * 20)
+	}
+	if n := atomic.LoadInt32(refreshes); n != 1 {
+		t.Fatalf("got %d refreshes before the token expired, want 1", n)
+	}
+	if token, _ := c.GetAuthToken(context.Background()); token != "token101" {
+		t.Errorf("got %q, want the refreshed token101", token)
+	}
+}
@@ -0,0 +1,161 @@
+package ahws
+
+import "encoding/json"
+
+type (
+	AuthTokenRequest struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Username     string `json:"username"`
+		Password     string `json:"password"`
+		GrantType    string `json:"grant_type"`
+	}
+
+	AuthTokenResponse struct {
+		AuthToken    string      `json:"access_token"`
+		ExpiresIn    json.Number `json:"expires_in"`
+		RefreshToken string      `json:"refresh_token"`
+		TokenType    string      `json:"token_type"`
+		ExpiresAt    int64       `json:"-"`
+	}
+
+	RefreshAuthTokenRequest struct {
+		GrantType    string `json:"grant_type"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	FunctionRoomGroupRequest struct {
+		LocationIDs []string `json:"LocationIds"`
+		// RecordStatus []string `json:"RecordStatus"`
+	}
+
+	FunctionRoomRequest struct {
+		LocationIDs  []string `json:"LocationIds"`
+		RecordStatus string   `json:"-"`
+	}
+
+	DefiniteEventSearchRequest struct {
+		BookingEventDateTimeBegin string `json:"BookingEventDateTimeBegin"`
+		BookingEventDateTimeEnd   string `json:"BookingEventDateTimeEnd"`
+		FunctionRoomGroupId       string `json:"FunctionRoomGroupId,omitempty"`
+		LocationId                string `json:"LocationId"`
+		MaxResultCount            int    `json:"MaxResultCount"`
+	}
+
+	DefiniteEventCacheSearchResults struct {
+		BookingEventDateTime string `json:"-"`
+		LocationId           string `json:"-"`
+		Found                bool
+	}
+
+	ErrorResponse struct {
+		Error     string `json:"error"`
+		ErrorDesc string `json:"error_description"`
+		GrantType string `json:"grant_type"`
+		ErrorURI  string `json:"error_uri"`
+	}
+
+	LocationResponse struct {
+		Name                               string      `json:"Name"`
+		Status                             string      `json:"Status"`
+		AddressLine1                       string      `json:"AddressLine1"`
+		AddressLine2                       string      `json:"AddressLine2"`
+		AddressLine3                       string      `json:"AddressLine3"`
+		City                               string      `json:"City"`
+		Country                            string      `json:"Country"`
+		CountryCode                        string      `json:"CountryCode"`
+		DistanceToNearestAirport           json.Number `json:"DistanceTonearestAirport"`
+		DistanceUnitOfMeasure              string      `json:"DistanceUnitOfMeasure"`
+		DrivetimeToNearestAirportInMinutes json.Number `json:"DrivetimeToNearestAirportInMinutes"`
+		Fax                                string      `json:"Fax"`
+		NearestAirportCode                 string      `json:"NearestAirportCode"`
+		Phone                              string      `json:"Phone"`
+		PostalCode                         string      `json:"PostalCode"`
+		SizeUnitofMeasure                  string      `json:"SizeUnitofMeasure"`
+		StateProvince                      string      `json:"StateProvince"`
+		TimeZone                           string      `json:"TimeZone"`
+		WebSiteUrl                         string      `json:"WebSiteUrl"`
+		Id                                 string      `json:"Id"`
+	}
+
+	FunctionRoomGroupsResponse struct {
+		Id                      string   `json:"Id"`
+		ExternalId              string   `json:"ExternalId"`
+		RecordStatus            string   `json:"RecordStatus"`
+		FunctionRoomIds         []string `json:"FunctionRoomIds"`
+		ExternalFunctionRoomIds []string `json:"ExternalFunctionRoomIds"`
+		LocationId              string   `json:"LocationId"`
+		ExternalLocationId      string   `json:"ExternalLocationId"`
+		AlternateDescription    string   `json:"AlternateDescription"`
+		AlternateName           string   `json:"AlternateName"`
+		Description             string   `json:"Description"`
+		Name                    string   `json:"Name"`
+	}
+
+	LocationFunctionRoomsResponse struct {
+		ExternalId                            string      `json:"ExternalId"`
+		ExternalCreatedById                   string      `json:"ExternalCreatedById"`
+		ExternalCreatedOn                     string      `json:"ExternalCreatedOn"`
+		ExternalModifiedById                  string      `json:"ExternalModifiedById"`
+		CreatedById                           string      `json:"CreatedById"`
+		CreatedOn                             string      `json:"CreatedOn"`
+		ModifiedBy                            string      `json:"ModifiedBy"`
+		ModifiedOn                            string      `json:"ModifiedOn"`
+		Abbreviation                          string      `json:"Abbreviation"`
+		Alias                                 string      `json:"Alias"`
+		AlternateFunctionRoomName             string      `json:"AlternateFunctionRoomName"`
+		Area                                  string      `json:"Area"`
+		Comments                              string      `json:"Comments"`
+		DefaultAdministrativeChargePercentage string      `json:"DefaultAdministrativeChargePercentage"`
+		DefaultGratuityPercentage             string      `json:"DefaultGratuityPercentage"`
+		DefaultSetupDurationMinutes           json.Number `json:"DefaultSetupDurationMinutes"`
+		DefaultTeardownDurationMinutes        json.Number `json:"DefaultTeardownDurationMinutes"`
+		ExternalBuildingId                    string      `json:"ExternalBuildingId"`
+		DefaultEventSetupTypeId               string      `json:"DefaultEventSetupTypeId"`
+		ExternalDefaultEventSetupTypeId       string      `json:"ExternalDefaultEventSetupTypeId"`
+		ExternalLevelId                       json.Number `json:"ExternalLevelId"`
+		Height                                json.Number `json:"Height"`
+		ImageUri                              string      `json:"ImageUri"`
+		Length                                json.Number `json:"Length"`
+		MaxAccessHeight                       json.Number `json:"MaxAccessHeight"`
+		MaxAccessWidth                        json.Number `json:"MaxAccessWidth"`
+		MinimumCapacity                       json.Number `json:"MinimumCapacity"`
+		MultiRoomBlockGroup                   string      `json:"MultiRoomBlockGroup"`
+		Sequence                              json.Number `json:"Sequence"`
+		WebSiteUrl                            string      `json:"WebSiteUrl"`
+		Width                                 json.Number `json:"Width"`
+		LocationId                            string      `json:"LocationId"`
+		ExternalLocationId                    string      `json:"ExternalLocationId"`
+		FunctionRoomType                      string      `json:"FunctionRoomType"`
+		Name                                  string      `json:"Name"`
+	}
+
+	DefiniteEventSearchResponse struct {
+		ExternalId                       string      `json:"ExternalId"`
+		AccountName                      string      `json:"AccountName"`
+		AlternateAccountName             string      `json:"AlternateAccountName"`
+		AlternateEventClassificationName string      `json:"AlternateEventClassificationName"`
+		AlternateFunctionRoomName        string      `json:"AlternateFunctionRoomName"`
+		BookingPostAs                    string      `json:"BookingPostAs"`
+		BookingTypeName                  string      `json:"BookingTypeName"`
+		EndDateTime                      string      `json:"EndDateTime"`
+		EventClassificationName          string      `json:"EventClassificationName"`
+		ExternalAccountId                string      `json:"ExternalAccountId"`
+		ExternalFunctionRoomId           string      `json:"ExternalFunctionRoomId"`
+		FunctionRoomName                 string      `json:"FunctionRoomName"`
+		LocationName                     string      `json:"LocationName"`
+		StartDateTime                    string      `json:"StartDateTime"`
+		AgreedAttendance                 json.Number `json:"AgreedAttendance"`
+		AlternateName                    string      `json:"AlternateName"`
+		Description                      string      `json:"Description"`
+		EstimatedAttendance              json.Number `json:"EstimatedAttendance"`
+		ForecastedAttendance             json.Number `json:"ForecastedAttendance"`
+		GuaranteedAttendance             json.Number `json:"GuaranteedAttendance"`
+		IsPosted                         bool        `json:"IsPosted"`
+		Name                             string      `json:"Name"`
+		SetAttendance                    json.Number `json:"SetAttendance"`
+		ExternalBookingId                string      `json:"ExternalBookingId"`
+		ExternalLocationId               string      `json:"ExternalLocationId"`
+		Id                               string      `json:"Id"`
+	}
+)
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"example.com/m/v2/ahws"
+)
+
+const kAPILocationsPath string = "/api/v1/locations/"
+
+type (
+	APIErrorResponse struct {
+		Error string `json:"error"`
+	}
+
+	LocationGroups struct {
+		LocationId string
+		Groups     []ahws.FunctionRoomGroupsResponse
+	}
+)
+
+// apiLocations serves the JSON API for a location:
+//
+//	/api/v1/locations/{id}/schedule[?group-id=&date=&days=&from=&to=&show-past=]
+//	/api/v1/locations/{id}/rooms/{room id or name}/current[?policy=]
+//	/api/v1/locations/{id}/groups
+func apiLocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Split the escaped path so room names may contain "/".
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), kAPILocationsPath), "/") {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid path: "+err.Error())
+			return
+		}
+		segments = append(segments, unescaped)
+	}
+	if segments[0] == "" {
+		writeAPIError(w, http.StatusBadRequest, "location id must be provided")
+		return
+	}
+	locationID := segments[0]
+
+	switch {
+	case len(segments) == 2 && segments[1] == "schedule":
+		loc := LocationTimeZone(r.Context(), locationID)
+		options, err := scheduleOptions(r.URL.Query(), loc)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		result, err := apiClient.SearchDefiniteEvents(r.Context(),
+			eventSearch(locationID, r.URL.Query().Get("group-id"), options.From, options.To))
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, buildScheduleScreen(locationID, loc, options, result, nil))
+
+	case len(segments) == 4 && segments[1] == "rooms" && segments[3] == "current":
+		if segments[2] == "" {
+			writeAPIError(w, http.StatusBadRequest, "room must be provided")
+			return
+		}
+		policy, _, err := coverOptions(r)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		loc := LocationTimeZone(r.Context(), locationID)
+		result, err := apiClient.SearchDefiniteEvents(r.Context(), todaysEventSearch(locationID, "", loc))
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		rooms := loadFunctionRooms(r.Context(), locationID)
+		writeJSON(w, http.StatusOK, buildCoverScreen(locationID, segments[2], rooms, loc, policy, result, nil))
+
+	case len(segments) == 2 && segments[1] == "groups":
+		groups, err := apiClient.GetFunctionRoomGroup(r.Context(), []string{locationID})
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		if groups == nil {
+			groups = []ahws.FunctionRoomGroupsResponse{}
+		}
+		writeJSON(w, http.StatusOK, LocationGroups{LocationId: locationID, Groups: groups})
+
+	default:
+		writeAPIError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// writeUpstreamError reports an AHWS failure for which there was no cached
+// data to fall back on.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	LogError(err)
+	if ahws.IsUnavailable(err) {
+		writeAPIError(w, http.StatusServiceUnavailable, "data unavailable: "+err.Error())
+		return
+	}
+	writeAPIError(w, http.StatusBadGateway, err.Error())
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, APIErrorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	LogError(json.NewEncoder(w).Encode(value))
+}
@@ -0,0 +1,25 @@
+// Package cachestore abstracts where cached AHWS responses and tokens live,
+// so replicas can share them, and saves the in-memory cache between restarts.
+package cachestore
+
+import "time"
+
+const (
+	// DefaultExpiration uses the backend's default TTL.
+	DefaultExpiration time.Duration = 0
+	// NoExpiration keeps the item until it is deleted.
+	NoExpiration time.Duration = -1
+)
+
+// Cache is a key value store with per-item expiry. Values must be registered
+// with encoding/gob for backends that store them outside the process.
+type Cache interface {
+	Get(key string) (any, bool)
+	// GetWithExpiration also returns when the item expires, the zero time if
+	// it never does.
+	GetWithExpiration(key string) (any, time.Time, bool)
+	Set(key string, value any, ttl time.Duration)
+	Delete(key string)
+	// Keys lists the keys of every unexpired item.
+	Keys() []string
+}
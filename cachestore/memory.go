@@ -0,0 +1,32 @@
+package cachestore
+
+import (
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Memory is an in-process Cache, the default backend.
+type Memory struct {
+	*cache.Cache
+}
+
+// NewMemory returns an empty Memory cache. Expired items are removed every
+// cleanupInterval.
+func NewMemory(defaultTTL time.Duration, cleanupInterval time.Duration) *Memory {
+	return &Memory{cache.New(defaultTTL, cleanupInterval)}
+}
+
+// NewMemoryFrom returns a Memory cache holding items, e.g. from a snapshot.
+func NewMemoryFrom(defaultTTL time.Duration, cleanupInterval time.Duration, items map[string]cache.Item) *Memory {
+	return &Memory{cache.NewFrom(defaultTTL, cleanupInterval, items)}
+}
+
+func (m *Memory) Keys() []string {
+	items := m.Items()
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	return keys
+}
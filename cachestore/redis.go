@@ -0,0 +1,320 @@
+package cachestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kRedisDialTimeout time.Duration = time.Second * 5
+	kRedisIOTimeout   time.Duration = time.Second * 5
+	kRedisPoolSize    int           = 8
+)
+
+// Redis is a Cache stored in Redis, or anything speaking its protocol, so
+// several replicas share tokens and AHWS responses. Values are gob encoded.
+type Redis struct {
+	addr       string
+	password   string
+	db         int
+	prefix     string
+	defaultTTL time.Duration
+
+	pool chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedis returns a Redis cache for a redis://[:password@]host:port[/db]
+// URL. Every key is stored with prefix.
+func NewRedis(redisURL string, prefix string, defaultTTL time.Duration) (*Redis, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, errors.New("cachestore: unsupported redis URL scheme " + u.Scheme)
+	}
+
+	r := &Redis{
+		addr:       u.Host,
+		prefix:     prefix,
+		defaultTTL: defaultTTL,
+		pool:       make(chan *redisConn, kRedisPoolSize),
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		r.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, errors.New("cachestore: redis database must be a number")
+		}
+	}
+
+	// Fail fast on a bad address or password.
+	if _, err := r.do("PING"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(key string) (any, bool) {
+	value, _, found := r.GetWithExpiration(key)
+	return value, found
+}
+
+func (r *Redis) GetWithExpiration(key string) (any, time.Time, bool) {
+	replies, err := r.pipeline([]string{"GET", r.prefix + key}, []string{"PTTL", r.prefix + key})
+	if err != nil {
+		logRedisError(err)
+		return nil, time.Time{}, false
+	}
+	data, ok := replies[0].([]byte)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	var value any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		logRedisError(fmt.Errorf("decoding %s: %w", key, err))
+		return nil, time.Time{}, false
+	}
+
+	var expiresAt time.Time
+	if ttl, ok := replies[1].(int64); ok && ttl > 0 {
+		expiresAt = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+	}
+	return value, expiresAt, true
+}
+
+func (r *Redis) Set(key string, value any, ttl time.Duration) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		logRedisError(fmt.Errorf("encoding %s: %w", key, err))
+		return
+	}
+
+	if ttl == DefaultExpiration {
+		ttl = r.defaultTTL
+	}
+	args := []string{"SET", r.prefix + key, buf.String()}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	if _, err := r.do(args...); err != nil {
+		logRedisError(err)
+	}
+}
+
+func (r *Redis) Delete(key string) {
+	if _, err := r.do("DEL", r.prefix+key); err != nil {
+		logRedisError(err)
+	}
+}
+
+func (r *Redis) Keys() []string {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", r.prefix+"*", "COUNT", "100")
+		if err != nil {
+			logRedisError(err)
+			return keys
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			logRedisError(errors.New("unexpected SCAN reply"))
+			return keys
+		}
+		next, _ := page[0].([]byte)
+		batch, _ := page[1].([]any)
+		for _, key := range batch {
+			if key, ok := key.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(key), r.prefix))
+			}
+		}
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return keys
+		}
+	}
+}
+
+// Close closes the idle connections.
+func (r *Redis) Close() {
+	for {
+		select {
+		case rc := <-r.pool:
+			rc.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (r *Redis) do(args ...string) (any, error) {
+	replies, err := r.pipeline(args)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline sends every command before reading the replies. An error reply to
+// any of them is returned as the error.
+func (r *Redis) pipeline(commands ...[]string) ([]any, error) {
+	rc, err := r.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	rc.conn.SetDeadline(time.Now().Add(kRedisIOTimeout))
+	var buf bytes.Buffer
+	for _, args := range commands {
+		writeRedisCommand(&buf, args)
+	}
+	if _, err := rc.conn.Write(buf.Bytes()); err != nil {
+		rc.conn.Close()
+		return nil, err
+	}
+
+	replies := make([]any, len(commands))
+	var replyErr error
+	for i := range commands {
+		reply, err := readRedisReply(rc.reader)
+		var serverErr redisError
+		if errors.As(err, &serverErr) {
+			replyErr = err
+			continue
+		}
+		if err != nil {
+			// The connection is in an unknown state.
+			rc.conn.Close()
+			return nil, err
+		}
+		replies[i] = reply
+	}
+
+	r.putConn(rc)
+	return replies, replyErr
+}
+
+func (r *Redis) getConn() (*redisConn, error) {
+	select {
+	case rc := <-r.pool:
+		return rc, nil
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", r.addr, kRedisDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	var setup [][]string
+	if r.password != "" {
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		rc.conn.SetDeadline(time.Now().Add(kRedisIOTimeout))
+		var buf bytes.Buffer
+		writeRedisCommand(&buf, args)
+		if _, err := conn.Write(buf.Bytes()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if _, err := readRedisReply(rc.reader); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+func (r *Redis) putConn(rc *redisConn) {
+	select {
+	case r.pool <- rc:
+	default:
+		rc.conn.Close()
+	}
+}
+
+func writeRedisCommand(buf *bytes.Buffer, args []string) {
+	fmt.Fprintf(buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readRedisReply reads one RESP reply. Bulk strings are returned as []byte,
+// integers as int64, arrays as []any and nil replies as nil.
+func readRedisReply(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		array := make([]any, count)
+		for i := range array {
+			if array[i], err = readRedisReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	}
+	return nil, errors.New("redis: unexpected reply " + line)
+}
+
+func logRedisError(err error) {
+	log.Println("cachestore:", err)
+}
@@ -0,0 +1,388 @@
+package cachestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testValue struct {
+	Name  string
+	Count int
+}
+
+func init() {
+	gob.Register(testValue{})
+}
+
+// fakeRedis is an in-process server speaking enough RESP for Redis. SCAN
+// returns two keys a page so Keys has to follow the cursor.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	items    map[string]fakeRedisItem
+	commands []string
+	// fail makes every command get an error reply.
+	fail bool
+}
+
+type fakeRedisItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: listener, password: password, items: map[string]fakeRedisItem{}}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeRedis) url(password string, db int) string {
+	if password != "" {
+		return fmt.Sprintf("redis://:%s@%s/%d", password, f.listener.Addr(), db)
+	}
+	return fmt.Sprintf("redis://%s/%d", f.listener.Addr(), db)
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		request, err := readRedisReply(reader)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range request.([]any) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		var reply bytes.Buffer
+		if args[0] != "AUTH" && !authenticated {
+			reply.WriteString("-NOAUTH Authentication required.\r\n")
+		} else if args[0] == "AUTH" {
+			authenticated = args[1] == f.password
+			if authenticated {
+				reply.WriteString("+OK\r\n")
+			} else {
+				reply.WriteString("-WRONGPASS invalid password\r\n")
+			}
+		} else {
+			f.run(&reply, args)
+		}
+		if _, err := conn.Write(reply.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) run(reply *bytes.Buffer, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args[0])
+	if f.fail {
+		reply.WriteString("-ERR fake failure\r\n")
+		return
+	}
+
+	var item fakeRedisItem
+	var found bool
+	if len(args) > 1 {
+		item, found = f.items[args[1]]
+		if found && !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+			delete(f.items, args[1])
+			found = false
+		}
+	}
+
+	switch args[0] {
+	case "PING":
+		reply.WriteString("+PONG\r\n")
+	case "SELECT":
+		reply.WriteString("+OK\r\n")
+	case "GET":
+		if !found {
+			reply.WriteString("$-1\r\n")
+			return
+		}
+		fmt.Fprintf(reply, "$%d\r\n%s\r\n", len(item.value), item.value)
+	case "PTTL":
+		switch {
+		case !found:
+			reply.WriteString(":-2\r\n")
+		case item.expiresAt.IsZero():
+			reply.WriteString(":-1\r\n")
+		default:
+			fmt.Fprintf(reply, ":%d\r\n", time.Until(item.expiresAt).Milliseconds())
+		}
+	case "SET":
+		item = fakeRedisItem{value: args[2]}
+		if len(args) == 5 && args[3] == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			item.expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		f.items[args[1]] = item
+		reply.WriteString("+OK\r\n")
+	case "DEL":
+		deleted := 0
+		if found {
+			delete(f.items, args[1])
+			deleted = 1
+		}
+		fmt.Fprintf(reply, ":%d\r\n", deleted)
+	case "SCAN":
+		var keys []string
+		for key := range f.items {
+			if strings.HasPrefix(key, strings.TrimSuffix(args[3], "*")) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		cursor, _ := strconv.Atoi(args[1])
+		end := cursor + 2
+		next := strconv.Itoa(end)
+		if end >= len(keys) {
+			end, next = len(keys), "0"
+		}
+		if cursor > len(keys) {
+			cursor = len(keys)
+		}
+		fmt.Fprintf(reply, "*2\r\n$%d\r\n%s\r\n*%d\r\n", len(next), next, end-cursor)
+		for _, key := range keys[cursor:end] {
+			fmt.Fprintf(reply, "$%d\r\n%s\r\n", len(key), key)
+		}
+	default:
+		fmt.Fprintf(reply, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (f *fakeRedis) sent(command string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, sent := range f.commands {
+		if sent == command {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeRedis) setFail(fail bool) {
+	f.mu.Lock()
+	f.fail = fail
+	f.mu.Unlock()
+}
+
+// newTestRedis connects to REDIS_URL if it's set, or else a fakeRedis.
+func newTestRedis(t *testing.T) (*Redis, *fakeRedis) {
+	t.Helper()
+	prefix := fmt.Sprintf("cachestore-test-%d:", time.Now().UnixNano())
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		r, err := NewRedis(redisURL, prefix, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			for _, key := range r.Keys() {
+				r.Delete(key)
+			}
+			r.Close()
+		})
+		return r, nil
+	}
+
+	fake := newFakeRedis(t, "")
+	r, err := NewRedis(fake.url("", 0), prefix, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return r, fake
+}
+
+func TestRedisGetSet(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	if _, found := r.Get("missing"); found {
+		t.Error("found a key that was never set")
+	}
+
+	want := testValue{Name: "events", Count: 3}
+	r.Set("value", want, time.Minute)
+	got, expiresAt, found := r.GetWithExpiration("value")
+	if !found || got != want {
+		t.Fatalf("got %v, %v, want %v", got, found, want)
+	}
+	if until := time.Until(expiresAt); until <= time.Second*55 || until > time.Minute {
+		t.Errorf("expires in %v, want about a minute", until)
+	}
+
+	r.Set("string", "token", DefaultExpiration)
+	if got, found := r.Get("string"); !found || got != "token" {
+		t.Errorf("got %v, %v, want token", got, found)
+	}
+	if _, expiresAt, _ := r.GetWithExpiration("string"); expiresAt.IsZero() {
+		t.Error("DefaultExpiration didn't use the default TTL")
+	}
+}
+
+func TestRedisExpiry(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	r.Set("short", "value", time.Millisecond*50)
+	if _, found := r.Get("short"); !found {
+		t.Fatal("not found before it expired")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if _, found := r.Get("short"); found {
+		t.Error("found after it expired")
+	}
+
+	r.Set("forever", "value", NoExpiration)
+	value, expiresAt, found := r.GetWithExpiration("forever")
+	if !found || value != "value" {
+		t.Fatalf("got %v, %v, want value", value, found)
+	}
+	if !expiresAt.IsZero() {
+		t.Errorf("got expiry %v for NoExpiration, want the zero time", expiresAt)
+	}
+}
+
+func TestRedisDelete(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	r.Set("key", "value", time.Minute)
+	r.Delete("key")
+	if _, found := r.Get("key"); found {
+		t.Error("found after Delete")
+	}
+	// Deleting a missing key is fine.
+	r.Delete("key")
+}
+
+func TestRedisKeys(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	var want []string
+	for i := 0; i < 7; i++ {
+		key := fmt.Sprintf("key%d", i)
+		r.Set(key, i, time.Minute)
+		want = append(want, key)
+	}
+
+	got := r.Keys()
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got keys %v, want %v", got, want)
+	}
+}
+
+func TestRedisErrorReplies(t *testing.T) {
+	r, fake := newTestRedis(t)
+	if fake == nil {
+		t.Skip("needs the fake server")
+	}
+
+	r.Set("key", "value", time.Minute)
+	fake.setFail(true)
+	if _, found := r.Get("key"); found {
+		t.Error("found a key when the server replied with an error")
+	}
+	r.Set("other", "value", time.Minute)
+	if keys := r.Keys(); len(keys) != 0 {
+		t.Errorf("got keys %v from an error reply", keys)
+	}
+
+	// The connection is still usable after error replies.
+	fake.setFail(false)
+	if value, found := r.Get("key"); !found || value != "value" {
+		t.Errorf("got %v, %v after the server recovered, want value", value, found)
+	}
+}
+
+func TestRedisAuthAndSelect(t *testing.T) {
+	fake := newFakeRedis(t, "secret")
+
+	if _, err := NewRedis(fake.url("wrong", 0), "", time.Minute); err == nil {
+		t.Error("connected with the wrong password")
+	}
+
+	r, err := NewRedis(fake.url("secret", 2), "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Set("key", "value", time.Minute)
+	if value, found := r.Get("key"); !found || value != "value" {
+		t.Errorf("got %v, %v, want value", value, found)
+	}
+	if !fake.sent("SELECT") {
+		t.Error("database wasn't selected")
+	}
+
+	if _, err := NewRedis("http://localhost", "", time.Minute); err == nil {
+		t.Error("accepted a URL that isn't redis://")
+	}
+}
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  string
+	}{
+		{"+OK\r\n", "OK"},
+		{":42\r\n", "42"},
+		{"$5\r\nhello\r\n", "[104 101 108 108 111]"},
+		{"$0\r\n\r\n", "[]"},
+		{"$-1\r\n", "<nil>"},
+		{"*-1\r\n", "<nil>"},
+		{"*2\r\n$1\r\na\r\n:1\r\n", "[[97] 1]"},
+		{"*2\r\n$-1\r\n$1\r\nb\r\n", "[<nil> [98]]"},
+	}
+	for _, test := range tests {
+		got, err := readRedisReply(bufio.NewReader(strings.NewReader(test.reply)))
+		if err != nil {
+			t.Errorf("%q: %v", test.reply, err)
+			continue
+		}
+		if fmt.Sprint(got) != test.want {
+			t.Errorf("%q: got %v, want %s", test.reply, got, test.want)
+		}
+	}
+
+	_, err := readRedisReply(bufio.NewReader(strings.NewReader("-ERR wrong type\r\n")))
+	if _, ok := err.(redisError); !ok || err.Error() != "redis: ERR wrong type" {
+		t.Errorf("got error %v, want the error reply", err)
+	}
+	if _, err := readRedisReply(bufio.NewReader(strings.NewReader("?\r\n"))); err == nil {
+		t.Error("accepted an unknown reply type")
+	}
+}
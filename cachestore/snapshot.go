@@ -0,0 +1,177 @@
+package cachestore
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	kSnapshotMagic string = "FBDS-CACHE-SNAPSHOT"
+	// SnapshotVersion is bumped whenever the snapshot layout changes.
+	SnapshotVersion int = 1
+)
+
+// ErrSnapshotMismatch is returned by LoadSnapshot for a snapshot written by a
+// different format version, or with different cached types.
+var ErrSnapshotMismatch = errors.New("cachestore: snapshot version or schema mismatch")
+
+// snapshotHeader is written before the items, so a snapshot can be rejected
+// before decoding items into types that have since changed.
+type snapshotHeader struct {
+	Magic   string
+	Version int
+	Schema  string
+	SavedAt time.Time
+}
+
+// SaveSnapshot writes the unexpired items in m to path with WriteFileAtomic.
+func SaveSnapshot(m *Memory, path string, schema string) error {
+	return WriteFileAtomic(path, func(w io.Writer) error {
+		encoder := gob.NewEncoder(w)
+		err := encoder.Encode(snapshotHeader{
+			Magic:   kSnapshotMagic,
+			Version: SnapshotVersion,
+			Schema:  schema,
+			SavedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(m.Items())
+	})
+}
+
+// WriteFileAtomic replaces path with what write writes. It's written to a
+// temporary file in the same directory and synced before being renamed over
+// path, so a crash leaves either the old or the new file, never a partial
+// one. If write fails path is left alone.
+func WriteFileAtomic(path string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Harmless after a successful rename.
+	defer os.Remove(tmp.Name())
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Make the rename itself durable.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// LoadSnapshot reads the items saved by SaveSnapshot, dropping any that have
+// expired since. A snapshot with a different version or schema returns
+// ErrSnapshotMismatch.
+func LoadSnapshot(path string, schema string) (map[string]cache.Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("cachestore: reading snapshot header: %w", err)
+	}
+	if header.Magic != kSnapshotMagic || header.Version != SnapshotVersion || header.Schema != schema {
+		return nil, ErrSnapshotMismatch
+	}
+
+	var items map[string]cache.Item
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("cachestore: reading snapshot items: %w", err)
+	}
+
+	now := time.Now().UnixNano()
+	for key, item := range items {
+		if item.Expiration > 0 && item.Expiration < now {
+			delete(items, key)
+		}
+	}
+	return items, nil
+}
+
+// QuarantineSnapshot moves a snapshot that couldn't be loaded out of the way,
+// keeping it for inspection, and returns where it was moved to.
+func QuarantineSnapshot(path string) (string, error) {
+	quarantined := path + ".bad-" + time.Now().Format("20060102T150405")
+	return quarantined, os.Rename(path, quarantined)
+}
+
+// SchemaFingerprint describes the shape of the types stored in the cache, so
+// a snapshot taken before a field was added, renamed or retyped is rejected
+// instead of silently decoding to zero values.
+func SchemaFingerprint(values ...any) string {
+	var b strings.Builder
+	for _, value := range values {
+		describeType(&b, reflect.TypeOf(value), map[reflect.Type]bool{})
+		b.WriteString(";")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+func describeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		b.WriteString("*")
+		describeType(b, t.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[]")
+		describeType(b, t.Elem(), seen)
+	case reflect.Map:
+		b.WriteString("map[")
+		describeType(b, t.Key(), seen)
+		b.WriteString("]")
+		describeType(b, t.Elem(), seen)
+	case reflect.Struct:
+		b.WriteString(t.String())
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		b.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			b.WriteString(field.Name + " ")
+			describeType(b, field.Type, seen)
+			b.WriteString(",")
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString(t.String())
+	}
+}
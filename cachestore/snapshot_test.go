@@ -0,0 +1,123 @@
+package cachestore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapping.json")
+
+	write := func(body string, err error) error {
+		return WriteFileAtomic(path, func(w io.Writer) error {
+			if _, err := io.WriteString(w, body); err != nil {
+				return err
+			}
+			return err
+		})
+	}
+	if err := write("first", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := write("second", errors.New("failed")); err == nil {
+		t.Error("a failed write returned no error")
+	}
+	if body, _ := os.ReadFile(path); string(body) != "first" {
+		t.Errorf("got %q after a failed write, want the old file", body)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want the temporary file removed", len(entries))
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	m := NewMemory(time.Minute, time.Minute)
+	m.Set("value", testValue{Name: "events", Count: 3}, time.Minute)
+
+	if err := SaveSnapshot(m, path, "schema"); err != nil {
+		t.Fatal(err)
+	}
+	items, err := LoadSnapshot(path, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := items["value"].Object; got != (testValue{Name: "events", Count: 3}) {
+		t.Errorf("got %v", got)
+	}
+
+	if _, err := LoadSnapshot(path, "other"); !errors.Is(err, ErrSnapshotMismatch) {
+		t.Errorf("got %v for another schema, want ErrSnapshotMismatch", err)
+	}
+}
+
+func TestLoadSnapshotDropsExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	m := NewMemory(time.Minute, time.Minute)
+	m.Set("kept", testValue{Name: "kept"}, time.Minute)
+	m.Set("expiring", testValue{Name: "expiring"}, 20*time.Millisecond)
+
+	if err := SaveSnapshot(m, path, "schema"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	items, err := LoadSnapshot(path, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := items["expiring"]; found {
+		t.Error("an item that expired after saving was loaded")
+	}
+	if _, found := items["kept"]; !found {
+		t.Error("an unexpired item wasn't loaded")
+	}
+}
+
+func TestLoadSnapshotCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.gob")
+	m := NewMemory(time.Minute, time.Minute)
+	m.Set("value", testValue{Name: "events", Count: 3}, time.Minute)
+	if err := SaveSnapshot(m, good, "schema"); err != nil {
+		t.Fatal(err)
+	}
+	body, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, corrupt := range map[string][]byte{
+		"truncated": body[:len(body)/2],
+		"garbage":   []byte("not a snapshot"),
+		"empty":     nil,
+	} {
+		path := filepath.Join(dir, name+".gob")
+		if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSnapshot(path, "schema"); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+			continue
+		}
+
+		quarantined, err := QuarantineSnapshot(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: snapshot is still at %s", name, path)
+		}
+		if kept, _ := os.ReadFile(quarantined); string(kept) != string(corrupt) {
+			t.Errorf("%s: quarantined snapshot doesn't match", name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+// CoverPolicy decides which event a cover screen shows when more than one is
+// in progress in the room, a breakfast running into a keynote or several
+// events in a combined room.
+type CoverPolicy string
+
+const (
+	// CoverPolicyStart prefers the event that started most recently.
+	CoverPolicyStart CoverPolicy = "start"
+	// CoverPolicyClassification prefers events by their position in
+	// coverClassificationPriority, then by start.
+	CoverPolicyClassification CoverPolicy = "classification"
+	// CoverPolicyAttendance prefers the event with the largest attendance,
+	// then by start.
+	CoverPolicyAttendance CoverPolicy = "attendance"
+)
+
+var (
+	coverPolicy                 = CoverPolicyStart
+	coverShowConcurrent         = false
+	coverClassificationPriority []string
+)
+
+func parseCoverPolicy(value string) (CoverPolicy, error) {
+	switch policy := CoverPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case CoverPolicyStart, CoverPolicyClassification, CoverPolicyAttendance:
+		return policy, nil
+	}
+	return "", errors.New("unknown cover selection policy: " + value)
+}
+
+// coverOptions reads the policy and concurrent query parameters, which
+// override the configured defaults for a single screen.
+func coverOptions(r *http.Request) (CoverPolicy, bool, error) {
+	policy, showConcurrent := coverPolicy, coverShowConcurrent
+	if value := r.URL.Query().Get("policy"); value != "" {
+		var err error
+		if policy, err = parseCoverPolicy(value); err != nil {
+			return "", false, err
+		}
+	}
+	if value := r.URL.Query().Get("concurrent"); value != "" {
+		var err error
+		if showConcurrent, err = strconv.ParseBool(value); err != nil {
+			return "", false, errors.New("concurrent: " + err.Error())
+		}
+	}
+	return policy, showConcurrent, nil
+}
+
+// coverCandidate is an event in progress in the room a cover screen is for.
+type coverCandidate struct {
+	event ahws.DefiniteEventSearchResponse
+	start time.Time
+	end   time.Time
+}
+
+// sortCoverCandidates orders candidates by policy, the preferred event first.
+func sortCoverCandidates(candidates []coverCandidate, policy CoverPolicy) {
+	byStart := func(i, j int) bool {
+		return candidates[i].start.After(candidates[j].start)
+	}
+
+	switch policy {
+	case CoverPolicyClassification:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a := classificationRank(candidates[i].event.EventClassificationName)
+			b := classificationRank(candidates[j].event.EventClassificationName)
+			if a != b {
+				return a < b
+			}
+			return byStart(i, j)
+		})
+	case CoverPolicyAttendance:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := attendance(candidates[i].event), attendance(candidates[j].event)
+			if a != b {
+				return a > b
+			}
+			return byStart(i, j)
+		})
+	default:
+		sort.SliceStable(candidates, byStart)
+	}
+}
+
+// classificationRank is the position of an EventClassificationName in
+// coverClassificationPriority, unlisted classifications rank last.
+func classificationRank(classification string) int {
+	for i, name := range coverClassificationPriority {
+		if strings.EqualFold(name, strings.TrimSpace(classification)) {
+			return i
+		}
+	}
+	return len(coverClassificationPriority)
+}
+
+// attendance is the agreed attendance of an event, or the best estimate AHWS
+// has before one is agreed.
+func attendance(event ahws.DefiniteEventSearchResponse) int64 {
+	for _, value := range []string{
+		event.AgreedAttendance.String(),
+		event.SetAttendance.String(),
+		event.GuaranteedAttendance.String(),
+		event.ForecastedAttendance.String(),
+		event.EstimatedAttendance.String(),
+	} {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 {
+			return int64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+func TestSortCoverCandidates(t *testing.T) {
+	oldPriority := coverClassificationPriority
+	t.Cleanup(func() { coverClassificationPriority = oldPriority })
+	coverClassificationPriority = []string{"Keynote", "Meal"}
+
+	day := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	candidate := func(name string, hour int, classification string, attendance map[string]string) coverCandidate {
+		event := ahws.DefiniteEventSearchResponse{Name: name, EventClassificationName: classification}
+		event.AgreedAttendance = json.Number(attendance["agreed"])
+		event.EstimatedAttendance = json.Number(attendance["estimated"])
+		start := day.Add(time.Duration(hour) * time.Hour)
+		return coverCandidate{event: event, start: start, end: start.Add(time.Hour * 4)}
+	}
+
+	tests := []struct {
+		name       string
+		policy     CoverPolicy
+		candidates []coverCandidate
+		want       []string
+	}{
+		{
+			name:   "start, latest first",
+			policy: CoverPolicyStart,
+			candidates: []coverCandidate{
+				candidate("Breakfast", 8, "", nil),
+				candidate("Keynote", 9, "", nil),
+			},
+			want: []string{"Keynote", "Breakfast"},
+		},
+		{
+			name:   "start ties keep their order",
+			policy: CoverPolicyStart,
+			candidates: []coverCandidate{
+				candidate("Breakfast", 8, "", nil),
+				candidate("Lunch", 10, "", nil),
+				candidate("Keynote", 10, "", nil),
+			},
+			want: []string{"Lunch", "Keynote", "Breakfast"},
+		},
+		{
+			name:   "classification, then start",
+			policy: CoverPolicyClassification,
+			candidates: []coverCandidate{
+				candidate("Breakfast", 8, "Meal", nil),
+				candidate("Breakout", 10, "Breakout", nil),
+				candidate("Opening", 7, " keynote ", nil),
+				candidate("Lunch", 10, "Meal", nil),
+			},
+			want: []string{"Opening", "Lunch", "Breakfast", "Breakout"},
+		},
+		{
+			name:   "unlisted classifications rank last",
+			policy: CoverPolicyClassification,
+			candidates: []coverCandidate{
+				candidate("Breakout", 10, "Breakout", nil),
+				candidate("Reception", 9, "", nil),
+				candidate("Lunch", 8, "Meal", nil),
+			},
+			want: []string{"Lunch", "Breakout", "Reception"},
+		},
+		{
+			name:   "attendance, then start",
+			policy: CoverPolicyAttendance,
+			candidates: []coverCandidate{
+				candidate("Breakfast", 8, "", map[string]string{"agreed": "100"}),
+				candidate("Expo", 7, "", map[string]string{"estimated": "200"}),
+				candidate("Lunch", 10, "", map[string]string{"agreed": "100", "estimated": "500"}),
+			},
+			want: []string{"Expo", "Lunch", "Breakfast"},
+		},
+		{
+			name:   "missing attendance ranks last",
+			policy: CoverPolicyAttendance,
+			candidates: []coverCandidate{
+				candidate("Reception", 10, "", nil),
+				candidate("Breakfast", 8, "", map[string]string{"agreed": "0", "estimated": "20"}),
+				candidate("Meeting", 9, "", map[string]string{"agreed": "not a number"}),
+			},
+			want: []string{"Breakfast", "Reception", "Meeting"},
+		},
+	}
+	for _, test := range tests {
+		sortCoverCandidates(test.candidates, test.policy)
+		var got []string
+		for _, candidate := range test.candidates {
+			got = append(got, candidate.event.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "log"
+
+const (
+	DebugLevelNone = iota
+	DebugLevelErrors
+	DebugLevelVerbose
+	DebugLevelTrace
+)
+
+// debugLevel is set from LOG_LEVEL, and passed on to the AHWS client.
+var debugLevel = DebugLevelErrors
+
+func DebugPrint(s any, logLevel int) {
+	if debugLevel >= logLevel {
+		log.Println("DEBUG:", s)
+	}
+}
+
+func LogError(err error) {
+	if err != nil && debugLevel >= DebugLevelErrors {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+// AHWS event datetimes are the property's wall clock time, without an offset.
+var eventTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	time.RFC3339Nano,
+}
+
+// EventStatus is where an event is relative to the location's current time.
+type EventStatus string
+
+const (
+	EventPast     EventStatus = "past"
+	EventLive     EventStatus = "live"
+	EventUpcoming EventStatus = "upcoming"
+)
+
+func eventStatus(start time.Time, end time.Time, now time.Time) EventStatus {
+	switch {
+	case !now.Before(end):
+		return EventPast
+	case !now.Before(start):
+		return EventLive
+	}
+	return EventUpcoming
+}
+
+// parseEventTime parses an AHWS StartDateTime/EndDateTime in loc.
+func parseEventTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized event time: " + value)
+}
+
+// parseEventSpan parses the start and end of an event. An end before the
+// start is a session running past midnight given the start's date, and is
+// moved to the next day.
+func parseEventSpan(event ahws.DefiniteEventSearchResponse, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := parseEventTime(event.StartDateTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseEventTime(event.EndDateTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// eventDays reports which day of a multi-day event on is, and how many days
+// it runs. Events shorter than a day are a single day, even when they cross
+// midnight.
+func eventDays(start time.Time, end time.Time, on time.Time) (int, int) {
+	if end.Sub(start) < time.Hour*24 {
+		return 1, 1
+	}
+	// An event ending at midnight doesn't run on the day it ends.
+	last := end
+	if last.Equal(startOfDay(last)) {
+		last = last.Add(-time.Nanosecond)
+	}
+	days := daysBetween(start, last) + 1
+	day := daysBetween(start, on) + 1
+	if day < 1 {
+		day = 1
+	}
+	if day > days {
+		day = days
+	}
+	return day, days
+}
+
+// eventTimeLabel is how an event time is displayed, with the date for events
+// running over more than one day.
+func eventTimeLabel(t time.Time, days int) string {
+	if days > 1 {
+		return t.Format("Jan 2 03:04 PM")
+	}
+	return t.Format("03:04 PM")
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days from a to b in a's location, ignoring DST.
+func daysBetween(a time.Time, b time.Time) int {
+	b = b.In(a.Location())
+	return int(math.Round(startOfDay(b).Sub(startOfDay(a)).Hours() / 24))
+}
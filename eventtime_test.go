@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+func TestParseEventSpan(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		name       string
+		start, end string
+		wantEnd    time.Time
+	}{
+		{"same day", "2024-03-10T09:00:00", "2024-03-10T17:00:00", time.Date(2024, time.March, 10, 17, 0, 0, 0, loc)},
+		{"overnight", "2024-03-10T22:00:00", "2024-03-10T02:00:00", time.Date(2024, time.March, 11, 2, 0, 0, 0, loc)},
+		{"zero length", "2024-03-10T09:00:00", "2024-03-10T09:00:00", time.Date(2024, time.March, 10, 9, 0, 0, 0, loc)},
+		{"multi-day", "2024-03-10T09:00:00", "2024-03-12T17:00:00", time.Date(2024, time.March, 12, 17, 0, 0, 0, loc)},
+	}
+	for _, test := range tests {
+		event := ahws.DefiniteEventSearchResponse{StartDateTime: test.start, EndDateTime: test.end}
+		start, end, err := parseEventSpan(event, loc)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := start.Format("2006-01-02T15:04:05"); got != test.start {
+			t.Errorf("%s: start %s, want %s", test.name, got, test.start)
+		}
+		if !end.Equal(test.wantEnd) {
+			t.Errorf("%s: end %v, want %v", test.name, end, test.wantEnd)
+		}
+	}
+
+	if _, _, err := parseEventSpan(ahws.DefiniteEventSearchResponse{StartDateTime: "soon", EndDateTime: "later"}, loc); err == nil {
+		t.Error("parsed an unrecognized time")
+	}
+}
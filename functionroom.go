@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"example.com/m/v2/ahws"
+)
+
+// kFunctionRoomIDPrefix marks a cover screen's room-id or a room in the
+// mapping as an external function room ID rather than a room name.
+const kFunctionRoomIDPrefix string = "id:"
+
+// FunctionRoom is a room by its external function room ID and name. Cover
+// screens and the mapping may use either.
+type FunctionRoom struct {
+	ID   string
+	Name string
+	// unknown is the ID or name a room was given by when it isn't one of the
+	// location's function rooms, so it can't be told which it is.
+	unknown string
+}
+
+// functionRoomIndex looks up the function rooms at a location by external ID
+// or name.
+type functionRoomIndex struct {
+	byID   map[string]FunctionRoom
+	byName map[string]FunctionRoom
+}
+
+// loadFunctionRooms indexes the function rooms at locationID. If AHWS can't
+// be reached and nothing is cached the index is empty, and every room is
+// unknown.
+func loadFunctionRooms(ctx context.Context, locationID string) functionRoomIndex {
+	rooms, err := apiClient.GetFunctionRoomsAtLocation(ctx, locationID)
+	LogError(err)
+
+	index := functionRoomIndex{byID: map[string]FunctionRoom{}, byName: map[string]FunctionRoom{}}
+	for _, room := range rooms {
+		if !strings.EqualFold(room.LocationId, locationID) {
+			continue
+		}
+		functionRoom := FunctionRoom{ID: room.ExternalId, Name: room.Name}
+		if _, found := index.byID[room.ExternalId]; room.ExternalId != "" && !found {
+			index.byID[room.ExternalId] = functionRoom
+		}
+		if _, found := index.byName[room.Name]; room.Name != "" && !found {
+			index.byName[room.Name] = functionRoom
+		}
+	}
+	return index
+}
+
+// resolve looks up key, a room name or an external function room ID prefixed
+// with kFunctionRoomIDPrefix. A key without the prefix that isn't a room's
+// name is tried as an ID as well. If it isn't found it's kept as an unknown
+// room, so screens and mappings set up before IDs were supported still work.
+func (index functionRoomIndex) resolve(key string) FunctionRoom {
+	if strings.HasPrefix(key, kFunctionRoomIDPrefix) {
+		id := strings.TrimPrefix(key, kFunctionRoomIDPrefix)
+		if room, found := index.byID[id]; found {
+			return room
+		}
+		return FunctionRoom{ID: id}
+	}
+
+	room, isName := index.byName[key]
+	byID, isID := index.byID[key]
+	switch {
+	case isName && isID && byID != room:
+		DebugPrint("room "+key+" is the name of one function room and the ID of another, using the name; prefix IDs with "+kFunctionRoomIDPrefix, DebugLevelErrors)
+		return room
+	case isName:
+		return room
+	case isID:
+		return byID
+	}
+	return FunctionRoom{unknown: key}
+}
+
+func eventFunctionRoom(event ahws.DefiniteEventSearchResponse) FunctionRoom {
+	return FunctionRoom{ID: event.ExternalFunctionRoomId, Name: event.FunctionRoomName}
+}
+
+// label is the room's name, or what it was given by if it's unknown.
+func (room FunctionRoom) label() string {
+	switch {
+	case room.Name != "":
+		return room.Name
+	case room.unknown != "":
+		return room.unknown
+	}
+	return room.ID
+}
+
+// same reports whether room and other are the same room, by ID when both have
+// one and otherwise by name. An unknown room matches either.
+func (room FunctionRoom) same(other FunctionRoom) bool {
+	switch {
+	case room.unknown != "":
+		return room.unknown == other.unknown || room.unknown == other.ID || room.unknown == other.Name
+	case other.unknown != "":
+		return other.same(room)
+	case room.ID != "" && other.ID != "":
+		return room.ID == other.ID
+	}
+	return room.Name != "" && room.Name == other.Name
+}
@@ -0,0 +1,115 @@
+package main
+
+import "testing"
+
+func testFunctionRooms(rooms ...FunctionRoom) functionRoomIndex {
+	index := functionRoomIndex{byID: map[string]FunctionRoom{}, byName: map[string]FunctionRoom{}}
+	for _, room := range rooms {
+		index.byID[room.ID] = room
+		index.byName[room.Name] = room
+	}
+	return index
+}
+
+func TestFunctionRoomSame(t *testing.T) {
+	boardroom := FunctionRoom{ID: "101", Name: "Boardroom"}
+	room101 := FunctionRoom{ID: "7", Name: "101"}
+
+	tests := []struct {
+		name string
+		a, b FunctionRoom
+		same bool
+	}{
+		{"same ID", boardroom, FunctionRoom{ID: "101", Name: "Boardroom (old name)"}, true},
+		{"ID of one is the name of the other", boardroom, room101, false},
+		{"different IDs, same name", boardroom, FunctionRoom{ID: "102", Name: "Boardroom"}, false},
+		{"name when one has no ID", boardroom, FunctionRoom{Name: "Boardroom"}, true},
+		{"no ID and another name", boardroom, FunctionRoom{Name: "101"}, false},
+		{"unknown by ID", FunctionRoom{unknown: "101"}, boardroom, true},
+		{"unknown by name", FunctionRoom{unknown: "Boardroom"}, boardroom, true},
+		{"unknown elsewhere", FunctionRoom{unknown: "Salon"}, boardroom, false},
+		{"nothing", FunctionRoom{}, FunctionRoom{}, false},
+	}
+	for _, test := range tests {
+		if got := test.a.same(test.b); got != test.same {
+			t.Errorf("%s: %+v.same(%+v) = %v, want %v", test.name, test.a, test.b, got, test.same)
+		}
+		if got := test.b.same(test.a); got != test.same {
+			t.Errorf("%s: %+v.same(%+v) = %v, want %v", test.name, test.b, test.a, got, test.same)
+		}
+	}
+}
+
+func TestFunctionRoomResolve(t *testing.T) {
+	boardroom := FunctionRoom{ID: "101", Name: "Boardroom"}
+	room101 := FunctionRoom{ID: "7", Name: "101"}
+	rooms := testFunctionRooms(boardroom, room101)
+
+	tests := []struct {
+		key   string
+		want  FunctionRoom
+		label string
+	}{
+		{"Boardroom", boardroom, "Boardroom"},
+		// A name is preferred to the same ID of another room.
+		{"101", room101, "101"},
+		{"id:101", boardroom, "Boardroom"},
+		{"id:7", room101, "101"},
+		// A key without the prefix is still tried as an ID.
+		{"7", room101, "101"},
+		{"id:Boardroom", FunctionRoom{ID: "Boardroom"}, "Boardroom"},
+		{"id:999", FunctionRoom{ID: "999"}, "999"},
+		{"Salon", FunctionRoom{unknown: "Salon"}, "Salon"},
+	}
+	for _, test := range tests {
+		got := rooms.resolve(test.key)
+		if got != test.want || got.label() != test.label {
+			t.Errorf("%s resolved to %+v labelled %q, want %+v labelled %q", test.key, got, got.label(), test.want, test.label)
+		}
+	}
+
+	// An unknown room given by ID only matches events in that room.
+	unknownID := rooms.resolve("id:999")
+	if !unknownID.same(FunctionRoom{ID: "999", Name: "Salon"}) || unknownID.same(FunctionRoom{ID: "998", Name: "999"}) {
+		t.Error("an unknown room given by ID matched by name")
+	}
+}
+
+// A room group's rooms are resolved like a cover screen's, so a group listing
+// the room named "101" doesn't take in the room with ID 101.
+func TestFindRoomInRoomGroupsByID(t *testing.T) {
+	boardroom := FunctionRoom{ID: "101", Name: "Boardroom"}
+	room101 := FunctionRoom{ID: "7", Name: "101"}
+	salon := FunctionRoom{ID: "8", Name: "Salon"}
+	rooms := testFunctionRooms(boardroom, room101, salon)
+
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	defer applyMapping(nil)
+	applyMapping([]RoomGroups{
+		{RoomGroup: "Salons", Rooms: []string{"Salon", "7"}},
+		{RoomGroup: "8", Rooms: []string{"Boardroom"}},
+		{RoomGroup: "Boardrooms", Rooms: []string{"id:101"}},
+	})
+
+	event := FunctionRoom{ID: "G1", Name: "Salons"}
+	if !FindRoomInRoomGroups(rooms, salon, event) || !FindRoomInRoomGroups(rooms, room101, event) {
+		t.Error("rooms in the group by name and by ID weren't found")
+	}
+	if FindRoomInRoomGroups(rooms, boardroom, event) {
+		t.Error("the Boardroom, ID 101, was found in a group listing room 7, named 101")
+	}
+
+	boardrooms := FunctionRoom{ID: "G2", Name: "Boardrooms"}
+	if !FindRoomInRoomGroups(rooms, boardroom, boardrooms) || FindRoomInRoomGroups(rooms, room101, boardrooms) {
+		t.Error("a group listing id:101 didn't take in only the room with ID 101")
+	}
+
+	// Looked up by the event's room name, not its ID, when the name is a group.
+	if FindRoomInRoomGroups(rooms, boardroom, FunctionRoom{ID: "8", Name: "Salons"}) {
+		t.Error("the group was looked up by the event's room ID")
+	}
+	if !FindRoomInRoomGroups(rooms, boardroom, FunctionRoom{ID: "8", Name: "Salon 8"}) {
+		t.Error("the group named by ID wasn't found")
+	}
+}
@@ -1,199 +1,104 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/gob"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
+	"flag"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
-	"reflect"
-	"regexp"
-	"runtime"
 	"sort"
 	"strconv"
-	"strings"
 	"syscall"
-	"text/template"
 	"time"
 
-	"github.com/patrickmn/go-cache"
+	"example.com/m/v2/ahws"
+	"example.com/m/v2/cachestore"
 )
 
-const ()
-
 type (
-	AuthTokenRequest struct {
-		ClientID     string `json:"client_id"`
-		ClientSecret string `json:"client_secret"`
-		Username     string `json:"username"`
-		Password     string `json:"password"`
-		GrantType    string `json:"grant_type"`
-	}
-
-	AuthTokenResponse struct {
-		AuthToken    string      `json:"access_token"`
-		ExpiresIn    json.Number `json:"expires_in"`
-		RefreshToken string      `json:"refresh_token"`
-		TokenType    string      `json:"token_type"`
-		ExpiresAt    int64       `json:"-"`
-	}
-
-	ScheduleScreen map[string][]DefiniteEventSearchResponse
-	CoverScreen    struct {
-		EventName string
-		StartTime string
-		EndTime   string
+	ScheduleEvent struct {
+		ahws.DefiniteEventSearchResponse
+		Start     time.Time
+		End       time.Time
+		Day       int `json:",omitempty"`
+		Days      int `json:",omitempty"`
+		Status    EventStatus
+		StartTime string `json:"-"`
+		EndTime   string `json:"-"`
+	}
+
+	ScheduleScreen struct {
+		LocationId string
+		TimeZone   string
+		From       time.Time
+		To         time.Time
+		// Groups has every event in the range, and Days the events on each
+		// day when the range is more than one day.
+		Groups      map[string][]ScheduleEvent
+		Days        []ScheduleDay  `json:",omitempty"`
+		Pages       []SchedulePage `json:"-"`
+		PageSeconds int            `json:"-"`
+		RangeLabel  string         `json:"-"`
+		Today       bool           `json:"-"`
+		Unavailable bool
+		Stale       bool
+		FetchedAt   time.Time
+		Version     string
+		LastUpdated string `json:"-"`
+	}
+	ScheduleDay struct {
+		Date   time.Time
+		Label  string `json:"-"`
+		Groups map[string][]ScheduleEvent
+	}
+
+	CoverScreen struct {
+		LocationId       string
+		RoomId           string
+		RoomName         string
+		EventName        string
+		Start            *time.Time   `json:",omitempty"`
+		End              *time.Time   `json:",omitempty"`
+		StartTime        string       `json:"-"`
+		EndTime          string       `json:"-"`
+		MinutesRemaining int          `json:",omitempty"`
+		Day              int          `json:",omitempty"`
+		Days             int          `json:",omitempty"`
+		Concurrent       []CoverEvent `json:",omitempty"`
+		ShowConcurrent   bool         `json:"-"`
+		Next             *CoverEvent  `json:",omitempty"`
+		StartingSoon     bool
+		// ChangesAt is when the screen next changes without the events
+		// changing: an event ending, or the next one starting soon or
+		// starting.
+		ChangesAt   *time.Time `json:",omitempty"`
+		TimeZone    string
+		Unavailable bool
+		Stale       bool
+		FetchedAt   time.Time
+		Version     string
+		LastUpdated string `json:"-"`
+	}
+
+	// CoverEvent is an event shown on a cover screen other than the current one.
+	CoverEvent struct {
+		Name      string
+		Room      string
+		Start     time.Time
+		End       time.Time
+		Day       int    `json:",omitempty"`
+		Days      int    `json:",omitempty"`
+		StartTime string `json:"-"`
+		EndTime   string `json:"-"`
 	}
 
 	Events struct {
-		FunctionRoomGroup FunctionRoomGroupsResponse
-		DefiniteEvent     DefiniteEventSearchResponse
-	}
-
-	RefreshAuthTokenRequest struct {
-		GrantType    string `json:"grant_type"`
-		RefreshToken string `json:"refresh_token"`
-	}
-
-	FunctionRoomGroupRequest struct {
-		LocationIDs []string `json:"LocationIds"`
-		// RecordStatus []string `json:"RecordStatus"`
-	}
-
-	FunctionRoomRequest struct {
-		LocationIDs  []string `json:"LocationIds"`
-		RecordStatus string   `json:"-"`
-	}
-
-	DefiniteEventSearchRequest struct {
-		BookingEventDateTimeBegin string `json:"BookingEventDateTimeBegin"`
-		BookingEventDateTimeEnd   string `json:"BookingEventDateTimeEnd"`
-		FunctionRoomGroupId       string `json:"FunctionRoomGroupId,omitempty"`
-		LocationId                string `json:"LocationId"`
-		MaxResultCount            int    `json:"MaxResultCount"`
-	}
-
-	DefiniteEventCacheSearchResults struct {
-		BookingEventDateTime string `-`
-		LocationId           string `-`
-		Found                bool
-	}
-
-	ErrorResponse struct {
-		Error     string `json:"error"`
-		ErrorDesc string `json:"error_description"`
-		GrantType string `json:"grant_type"`
-		ErrorURI  string `json:"error_uri"`
-	}
-
-	LocationResponse struct {
-		Name                               string      `json:"Name"`
-		Status                             string      `json:"Status"`
-		AddressLine1                       string      `json:"AddressLine1"`
-		AddressLine2                       string      `json:"AddressLine2"`
-		AddressLine3                       string      `json:"AddressLine3"`
-		City                               string      `json:"City"`
-		Country                            string      `json:"Country"`
-		CountryCode                        string      `json:"CountryCode"`
-		DistanceToNearestAirport           json.Number `json:"DistanceTonearestAirport"`
-		DistanceUnitOfMeasure              string      `json:"DistanceUnitOfMeasure"`
-		DrivetimeToNearestAirportInMinutes json.Number `json:"DrivetimeToNearestAirportInMinutes"`
-		Fax                                string      `json:"Fax"`
-		NearestAirportCode                 string      `json:"NearestAirportCode"`
-		Phone                              string      `json:"Phone"`
-		PostalCode                         string      `json:"PostalCode"`
-		SizeUnitofMeasure                  string      `json:"SizeUnitofMeasure"`
-		StateProvince                      string      `json:"StateProvince"`
-		TimeZone                           string      `json:"TimeZone"`
-		WebSiteUrl                         string      `json:"WebSiteUrl"`
-		Id                                 string      `json:"Id"`
-	}
-
-	FunctionRoomGroupsResponse struct {
-		Id                      string   `json:"Id"`
-		ExternalId              string   `json:"ExternalId"`
-		RecordStatus            string   `json:"RecordStatus"`
-		FunctionRoomIds         []string `json:"FunctionRoomIds"`
-		ExternalFunctionRoomIds []string `json:"ExternalFunctionRoomIds"`
-		LocationId              string   `json:"LocationId"`
-		ExternalLocationId      string   `json:"ExternalLocationId"`
-		AlternateDescription    string   `json:"AlternateDescription"`
-		AlternateName           string   `json:"AlternateName"`
-		Description             string   `json:"Description"`
-		Name                    string   `json:"Name"`
-	}
-
-	LocationFunctionRoomsResponse struct {
-		ExternalId                            string      `json:"ExternalId"`
-		ExternalCreatedById                   string      `json:"ExternalCreatedById"`
-		ExternalCreatedOn                     string      `json:"ExternalCreatedOn"`
-		ExternalModifiedById                  string      `json:"ExternalModifiedById"`
-		CreatedById                           string      `json:"CreatedById"`
-		CreatedOn                             string      `json:"CreatedOn"`
-		ModifiedBy                            string      `json:"ModifiedBy"`
-		ModifiedOn                            string      `json:"ModifiedOn"`
-		Abbreviation                          string      `json:"Abbreviation"`
-		Alias                                 string      `json:"Alias"`
-		AlternateFunctionRoomName             string      `json:"AlternateFunctionRoomName"`
-		Area                                  string      `json:"Area"`
-		Comments                              string      `json:"Comments"`
-		DefaultAdministrativeChargePercentage string      `json:"DefaultAdministrativeChargePercentage"`
-		DefaultGratuityPercentage             string      `json:"DefaultGratuityPercentage"`
-		DefaultSetupDurationMinutes           json.Number `json:"DefaultSetupDurationMinutes"`
-		DefaultTeardownDurationMinutes        json.Number `json:"DefaultTeardownDurationMinutes"`
-		ExternalBuildingId                    string      `json:"ExternalBuildingId"`
-		DefaultEventSetupTypeId               string      `json:"DefaultEventSetupTypeId"`
-		ExternalDefaultEventSetupTypeId       string      `json:"ExternalDefaultEventSetupTypeId"`
-		ExternalLevelId                       json.Number `json:"ExternalLevelId"`
-		Height                                json.Number `json:"Height"`
-		ImageUri                              string      `json:"ImageUri"`
-		Length                                json.Number `json:"Length"`
-		MaxAccessHeight                       json.Number `json:"MaxAccessHeight"`
-		MaxAccessWidth                        json.Number `json:"MaxAccessWidth"`
-		MinimumCapacity                       json.Number `json:"MinimumCapacity"`
-		MultiRoomBlockGroup                   string      `json:"MultiRoomBlockGroup"`
-		Sequence                              json.Number `json:"Sequence"`
-		WebSiteUrl                            string      `json:"WebSiteUrl"`
-		Width                                 json.Number `json:"Width"`
-		LocationId                            string      `json:"LocationId"`
-		ExternalLocationId                    string      `json:"ExternalLocationId"`
-		FunctionRoomType                      string      `json:"FunctionRoomType"`
-		Name                                  string      `json:"Name"`
-	}
-
-	DefiniteEventSearchResponse struct {
-		ExternalId                       string      `json:"ExternalId"`
-		AccountName                      string      `json:"AccountName"`
-		AlternateAccountName             string      `json:"AlternateAccountName"`
-		AlternateEventClassificationName string      `json:"AlternateEventClassificationName"`
-		AlternateFunctionRoomName        string      `json:"AlternateFunctionRoomName"`
-		BookingPostAs                    string      `json:"BookingPostAs"`
-		BookingTypeName                  string      `json:"BookingTypeName"`
-		EndDateTime                      string      `json:"EndDateTime"`
-		EventClassificationName          string      `json:"EventClassificationName"`
-		ExternalAccountId                string      `json:"ExternalAccountId"`
-		ExternalFunctionRoomId           string      `json:"ExternalFunctionRoomId"`
-		FunctionRoomName                 string      `json:"FunctionRoomName"`
-		LocationName                     string      `json:"LocationName"`
-		StartDateTime                    string      `json:"StartDateTime"`
-		AgreedAttendance                 json.Number `json:"AgreedAttendance"`
-		AlternateName                    string      `json:"AlternateName"`
-		Description                      string      `json:"Description"`
-		EstimatedAttendance              json.Number `json:"EstimatedAttendance"`
-		ForecastedAttendance             json.Number `json:"ForecastedAttendance"`
-		GuaranteedAttendance             json.Number `json:"GuaranteedAttendance"`
-		IsPosted                         bool        `json:"IsPosted"`
-		Name                             string      `json:"Name"`
-		SetAttendance                    json.Number `json:"SetAttendance"`
-		ExternalBookingId                string      `json:"ExternalBookingId"`
-		ExternalLocationId               string      `json:"ExternalLocationId"`
-		Id                               string      `json:"Id"`
+		FunctionRoomGroup ahws.FunctionRoomGroupsResponse
+		DefiniteEvent     ahws.DefiniteEventSearchResponse
 	}
 
 	RoomGroups struct {
@@ -202,59 +107,51 @@ type (
 	}
 )
 
-const (
-	// kAHWSBaseURL string = "https://api-release.amadeus-hospitality.com"
-	// kAuthPath    string = "/release/2.0/OAuth2"
-	// kAPIPath     string = "/api/release"
-	kAHWSBaseURL                 string        = "https://api.newmarketinc.com"
-	kAuthPath                    string        = "/2.0/OAuth2"
-	kAPIPath                     string        = "/api"
-	kAccessTokenPath             string        = kAuthPath + "/AccessToken"
-	kRefreshAccessTokenPath      string        = kAuthPath + "/RefreshAccessToken"
-	kLocationSearchPath          string        = kAPIPath + "/Location/Search"
-	kLocationsByExternalID       string        = kAPIPath + "/location/ExternalLocationId"
-	kLocationsByID               string        = kAPIPath + "/location/LocationId"
-	kFunctionRoomGroupSearchPath string        = kAPIPath + "/functionroomgroup/Search"
-	kFunctionRoomsSearchPath     string        = kAPIPath + "/functionroom/Search"
-	kDefiniteEventSearchPath     string        = kAPIPath + "/bookingEvent/DefiniteEventSearch"
-	kTTL                         time.Duration = time.Minute * 15
-)
-
-const (
-	DebugLevelNone = iota
-	DebugLevelErrors
-	DebugLevelVerbose
-	DebugLevelTrace
-)
-
-const (
-	CacheLevelNone = iota
-	CacheLevelAuth
-	CacheLevelAll
-)
-
 var (
-	ocpApimSubscriptionKey string = os.Getenv("AHWS_APIM_SUBSCRIPTION_KEY")
-	apiCache                      = cache.New(15*time.Minute, 20*time.Minute)
-	debugLevel                    = 1
-	cacheLevel                    = CacheLevelAll
-	HasAuthToken                  = false
-
-	authRequest = AuthTokenRequest{
-		ClientID:     os.Getenv("AHWS_CLIENT_ID"),
-		ClientSecret: os.Getenv("AHWS_CLIENT_SECRET"),
-		Username:     os.Getenv("AHWS_USERNAME"),
-		Password:     os.Getenv("AHWS_PASSWORD"),
-		GrantType:    "password",
+	apiCache   cachestore.Cache = cachestore.NewMemory(15*time.Minute, 20*time.Minute)
+	apiClient  *ahws.Client
+	cacheLevel = ahws.CacheLevelAll
+
+	// startingSoonWindow is how long before the next event a cover screen
+	// with no current event shows it as starting soon.
+	startingSoonWindow = time.Minute * 15
+
+	// eventSearchLookBackDays is how many days before the first day shown
+	// AHWS is searched from, so a session running past midnight or a later
+	// day of a multi-day event is still found. Screens filter the results by
+	// each event's span. An event that started further back than this is
+	// missed, so it must be at least as long as the longest booking, in days.
+	eventSearchLookBackDays = 3
+
+	// cachedTypes are stored in the cache as interface values, and make up the
+	// snapshot schema.
+	cachedTypes = []any{
+		ahws.AuthTokenResponse{},
+		[]ahws.LocationResponse{},
+		[]ahws.FunctionRoomGroupsResponse{},
+		[]ahws.LocationFunctionRoomsResponse{},
+		[]ahws.DefiniteEventSearchResponse{},
+		ahws.CachedResponse{},
+	}
+	snapshotSchema string
+	snapshotPath   = "cache.gob"
+
+	credentials = ahws.Credentials{
+		ClientID:        os.Getenv("AHWS_CLIENT_ID"),
+		ClientSecret:    os.Getenv("AHWS_CLIENT_SECRET"),
+		Username:        os.Getenv("AHWS_USERNAME"),
+		Password:        os.Getenv("AHWS_PASSWORD"),
+		SubscriptionKey: os.Getenv("AHWS_APIM_SUBSCRIPTION_KEY"),
 	}
 )
 
 func main() {
-	if authRequest.ClientID == "" ||
-		authRequest.Username == "" ||
-		authRequest.Password == "" ||
-		authRequest.GrantType == "" ||
-		ocpApimSubscriptionKey == "" {
+	templateDir := flag.String("template-dir", os.Getenv("TEMPLATE_DIR"), "read the screen templates from `dir` instead of the ones built in")
+	dev := flag.Bool("dev", boolFromEnv("DEV_MODE", false), "reload the screen templates when they change, from the working directory unless -template-dir is set")
+	checkLocation := flag.String("check-mapping", "", "check the mapping file against the function rooms at location `id` in AHWS, then exit")
+	flag.Parse()
+
+	if !credentials.Valid() {
 		log.Panicln("FATAL: Environment Vars for authentication not set")
 	}
 
@@ -266,15 +163,105 @@ func main() {
 		debugLevel = logLevel
 	}
 
-	gob.Register(AuthTokenResponse{})
-	gob.Register([]LocationResponse{})
-	gob.Register([]FunctionRoomGroupsResponse{})
-	gob.Register(FunctionRoomGroupsResponse{})
-	gob.Register([]DefiniteEventSearchResponse{})
-	gob.Register(RoomGroups{})
-	gob.Register([]RoomGroups{})
+	if tz, has := os.LookupEnv("DEFAULT_TIME_ZONE"); has && tz != "" {
+		if _, err := loadZone(tz); err != nil {
+			log.Panicln("FATAL: DEFAULT_TIME_ZONE:", err)
+		}
+		defaultTimeZone = tz
+	}
+
+	if err := screenTemplates.load(*templateDir, *dev); err != nil {
+		log.Panicln("FATAL: Could not parse templates: ", err)
+	}
+
+	for _, value := range cachedTypes {
+		gob.Register(value)
+	}
+	snapshotSchema = cachestore.SchemaFingerprint(cachedTypes...)
+	if path, has := os.LookupEnv("CACHE_SNAPSHOT_PATH"); has && path != "" {
+		snapshotPath = path
+	}
+
+	if path, has := os.LookupEnv("MAPPING_PATH"); has && path != "" {
+		mappingPath = path
+	}
+	if path, has := os.LookupEnv("MAPPING_AUDIT_PATH"); has && path != "" {
+		mappingAuditPath = path
+	}
+	loadAdminUsers(os.Getenv("ADMIN_USERS"))
+
+	if os.Getenv("CACHE_BACKEND") == "redis" {
+		prefix, has := os.LookupEnv("REDIS_KEY_PREFIX")
+		if !has {
+			prefix = "fbds:"
+		}
+		redisCache, err := cachestore.NewRedis(os.Getenv("REDIS_URL"), prefix, 15*time.Minute)
+		if err != nil {
+			log.Panicln("FATAL: Could not connect to redis: ", err)
+		}
+		defer redisCache.Close()
+		apiCache = redisCache
+	}
 	loadCacheGob()
-	loadJSONMapping()
+	LogError(reloadMapping())
+
+	apiClient = ahws.NewClient(credentials, os.Getenv("AHWS_BASE_URL"), nil, apiCache)
+	apiClient.CacheLevel = cacheLevel
+	apiClient.RequestTimeout = durationFromEnv("AHWS_REQUEST_TIMEOUT", apiClient.RequestTimeout)
+	apiClient.MaxRetries = intFromEnv("AHWS_MAX_RETRIES", apiClient.MaxRetries)
+	apiClient.BreakerFailures = intFromEnv("AHWS_BREAKER_FAILURES", apiClient.BreakerFailures)
+	apiClient.BreakerCooldown = durationFromEnv("AHWS_BREAKER_COOLDOWN", apiClient.BreakerCooldown)
+	apiClient.StaleTTL = durationFromEnv("AHWS_STALE_TTL", apiClient.StaleTTL)
+	apiClient.Logger = log.Default()
+	apiClient.LogLevel = debugLevel
+
+	if *checkLocation != "" {
+		os.Exit(runMappingCheck(*checkLocation))
+	}
+
+	eventSearchLookBackDays = intFromEnv("EVENT_SEARCH_LOOKBACK_DAYS", eventSearchLookBackDays)
+	if eventSearchLookBackDays < 0 {
+		log.Panicln("FATAL: EVENT_SEARCH_LOOKBACK_DAYS must not be negative")
+	}
+	startingSoonWindow = durationFromEnv("COVER_STARTING_SOON", startingSoonWindow)
+	if value := os.Getenv("COVER_SELECTION_POLICY"); value != "" {
+		policy, err := parseCoverPolicy(value)
+		if err != nil {
+			log.Panicln("FATAL: COVER_SELECTION_POLICY:", err)
+		}
+		coverPolicy = policy
+	}
+	coverShowConcurrent = boolFromEnv("COVER_SHOW_CONCURRENT", coverShowConcurrent)
+	coverClassificationPriority = listFromEnv("COVER_CLASSIFICATION_PRIORITY")
+	schedulePastGrace = durationFromEnv("SCHEDULE_PAST_GRACE", schedulePastGrace)
+	scheduleRowsPerPage = intFromEnv("SCHEDULE_ROWS_PER_PAGE", scheduleRowsPerPage)
+	schedulePageSeconds = intFromEnv("SCHEDULE_PAGE_SECONDS", schedulePageSeconds)
+	if schedulePageSeconds < 1 {
+		log.Panicln("FATAL: SCHEDULE_PAGE_SECONDS must be at least 1")
+	}
+	scheduleGroupPerPage = boolFromEnv("SCHEDULE_GROUP_PER_PAGE", scheduleGroupPerPage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go prefetcher(ctx,
+		durationFromEnv("PREFETCH_INTERVAL", kPrefetchInterval),
+		listFromEnv("PREFETCH_LOCATION_IDS"),
+		listFromEnv("PREFETCH_GROUP_IDS"))
+
+	go snapshotter(ctx, durationFromEnv("CACHE_SNAPSHOT_INTERVAL", time.Minute*5))
+	go mappingWatcher(ctx, durationFromEnv("MAPPING_RELOAD_INTERVAL", time.Second*10))
+	go mappingGenerator(ctx,
+		durationFromEnv("MAPPING_GENERATE_INTERVAL", time.Hour),
+		listFromEnv("MAPPING_GENERATE_LOCATION_IDS"))
+
+	// SIGHUP reloads mapping.json without waiting for the watcher.
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			DebugPrint("caught SIGHUP, reloading "+mappingPath, DebugLevelErrors)
+			LogError(reloadMapping())
+		}
+	}()
 
 	cancelChan := make(chan os.Signal, 1)
 
@@ -284,624 +271,455 @@ func main() {
 	sig := <-cancelChan
 	log.Printf("Caught signal %v, waiting 3 seconds for graceful shutdown.", sig)
 
+	cancel()
+	apiClient.Close()
 	saveCacheGob()
 
 	time.Sleep(time.Second * 3)
 	log.Println("Goodbye.")
 }
 
-func scheduleView(w http.ResponseWriter, definiteEvents []DefiniteEventSearchResponse) {
-	events := ScheduleScreen{}
-	w.Header().Add("Content-Type", "text/html")
-	tmpl, err := template.ParseFiles("schedule_screen.html.template")
-	LogError(err)
-
-	for _, event := range definiteEvents {
-		if !event.IsPosted {
-			continue
-		}
-
-		re := regexp.MustCompile("T(.*:.*):.*$")
-		layout1 := "15:04"
-		startTime := re.FindStringSubmatch(event.StartDateTime)
-		endTime := re.FindStringSubmatch(event.EndDateTime)
-		start24Hr, err := time.Parse(layout1, startTime[1])
-		if err != nil {
-			LogError(err)
-		}
-		end24Hr, err := time.Parse(layout1, endTime[1])
-		if err != nil {
-			LogError(err)
-		}
-		event.StartDateTime = start24Hr.Format("03:04 PM")
-		event.EndDateTime = end24Hr.Format("03:04 PM")
-		events[event.BookingPostAs] = append(events[event.BookingPostAs], event)
+func intFromEnv(name string, fallback int) int {
+	value, has := os.LookupEnv(name)
+	if !has || value == "" {
+		return fallback
 	}
-	for _, v := range events {
-		sort.Slice(v, func(i, j int) bool {
-			return v[i].StartDateTime < v[j].StartDateTime
-		})
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Panicln("FATAL: " + name + " must be an integer")
 	}
-	LogError(tmpl.Execute(w, events))
-
+	return i
 }
 
-func FindRoomInRoomGroups(roomName string, eventRoom string) bool {
-	cached, found := apiCache.Get("RoomGroupByGroupName:" + eventRoom)
-	if found {
-		for _, room := range cached.(RoomGroups).Rooms {
-			if room == roomName {
-				return true
-			}
-		}
+func boolFromEnv(name string, fallback bool) bool {
+	value, has := os.LookupEnv(name)
+	if !has || value == "" {
+		return fallback
 	}
-	return false
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Panicln("FATAL: " + name + " must be true or false")
+	}
+	return b
 }
 
-func coverView(w http.ResponseWriter, roomId string, definiteEvents []DefiniteEventSearchResponse) {
-	cs := CoverScreen{}
-	w.Header().Add("Content-Type", "text/html")
-
-	tmpl, err := template.ParseFiles("cover_screen.html.template")
-	LogError(err)
-
-	for _, event := range definiteEvents {
-		if !event.IsPosted || (roomId != event.FunctionRoomName && !FindRoomInRoomGroups(roomId, event.FunctionRoomName)) {
-			continue
-		}
-
-		// NOTE: We don't know what tz... Defaults to EST, can accept the tz from the client if required.
-		loc, err := time.LoadLocation("America/New_York")
-		LogError(err)
-
-		now := time.Now().In(loc)
-
-		re := regexp.MustCompile("T(.*:.*):.*$")
-		startTime := re.FindStringSubmatch(event.StartDateTime)
-		endTime := re.FindStringSubmatch(event.EndDateTime)
-
-		startTimeSlice := strings.Split(startTime[1], ":")
-		startTimeHour, _ := strconv.Atoi(startTimeSlice[0])
-		startTimeMinute, _ := strconv.Atoi(startTimeSlice[1])
-
-		endTimeSlice := strings.Split(endTime[1], ":")
-		endTimeHour, _ := strconv.Atoi(endTimeSlice[0])
-		endTimeMinute, _ := strconv.Atoi(endTimeSlice[1])
-
-		startDate := time.Date(
-			now.Year(),
-			now.Month(),
-			now.Day(), startTimeHour, startTimeMinute, 0, 0, loc)
-
-		endDate := time.Date(
-			now.Year(),
-			now.Month(),
-			now.Day(), endTimeHour, endTimeMinute, 0, 0, loc)
-
-		currentTime := now
-
-		// NOTE: For manipulating time for testing.
-		// currentTime = time.Date(
-		// 	time.Now().Year(),
-		// 	time.Now().Month(),
-		// 	time.Now().Add(time.Hour*24).Day(), 00, 13, 0, 0, loc)
-
-		if startDate.Unix() <= currentTime.Unix() && currentTime.Unix() < endDate.Unix() {
-			start24Hr, err := time.Parse("15:04", startTime[1])
-			if err != nil {
-				LogError(err)
-			}
-
-			end24Hr, err := time.Parse("15:04", endTime[1])
-			if err != nil {
-				LogError(err)
-			}
-
-			cs.StartTime = start24Hr.Format("03:04 PM")
-			cs.EndTime = end24Hr.Format("03:04 PM")
-			cs.EventName = event.Name
-		}
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value, has := os.LookupEnv(name)
+	if !has || value == "" {
+		return fallback
 	}
-
-	if cs.EventName == "" {
-		cs.EventName = "No Current Event"
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Panicln("FATAL: " + name + " must be a duration, e.g. 10s")
 	}
-
-	err = tmpl.Execute(w, cs)
-	LogError(err)
+	return d
 }
 
-func httpServer(cancelChan chan<- os.Signal) {
-	http.HandleFunc("/view/cover", func(w http.ResponseWriter, r *http.Request) {
-		if !r.URL.Query().Has("location-id") || !r.URL.Query().Has("room-id") {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("location-id, and room-id must be provided"))
-			return
-		}
-
-		definiteEvents, _ := GetBookingEventDetails(DefiniteEventSearchRequest{
-			LocationId:                r.URL.Query().Get("location-id"),
-			BookingEventDateTimeBegin: time.Now().Format("2006-01-02"),
-			BookingEventDateTimeEnd:   time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
-		})
-		coverView(w, r.URL.Query().Get("room-id"), definiteEvents)
-	})
-
-	http.HandleFunc("/view/schedule", func(w http.ResponseWriter, r *http.Request) {
-		if !r.URL.Query().Has("location-id") {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("location-id must be provided"))
-			return
-		}
-
-		w.Header().Add("Content-Type", "text/html")
-		definiteEvents, _ := GetBookingEventDetails(DefiniteEventSearchRequest{
-			LocationId:                r.URL.Query().Get("location-id"),
-			FunctionRoomGroupId:       r.URL.Query().Get("group-id"),
-			BookingEventDateTimeBegin: time.Now().Format("2006-01-02"),
-			BookingEventDateTimeEnd:   time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
-		})
-		scheduleView(w, definiteEvents)
-	})
-
-	port, ok := os.LookupEnv("PORT")
-	if !ok {
-		port = "8080"
-	}
-
-	http.ListenAndServe(":"+port, nil)
+func scheduleView(w http.ResponseWriter, events ScheduleScreen) {
+	screenTemplates.render(w, kScheduleTemplate, events)
 }
 
-func GetAuthToken() string {
-	authToken := ""
-	if cacheLevel == CacheLevelNone {
-		authToken = authenticate()
+// buildScheduleScreen groups the posted events in the options' date range by
+// who they are posted as, and by day if that is more than one day. On a board
+// showing today, events that finished more than schedulePastGrace ago are
+// left out unless options.ShowPast.
+func buildScheduleScreen(locationID string, loc *time.Location, options ScheduleOptions, result ahws.DefiniteEventsResult, eventsErr error) ScheduleScreen {
+	now := time.Now().In(loc)
+	from, to := options.From, options.To
+	events := ScheduleScreen{
+		LocationId:  locationID,
+		TimeZone:    loc.String(),
+		From:        from,
+		To:          to,
+		Groups:      map[string][]ScheduleEvent{},
+		Today:       from.Equal(startOfDay(now)) && daysBetween(from, to) == 1,
+		Unavailable: eventsErr != nil,
+		Stale:       result.Stale,
+		FetchedAt:   result.FetchedAt,
+		Version:     screenVersion(result.Events, loc, now, scheduleBoundaries),
+		LastUpdated: result.FetchedAt.In(loc).Format("03:04 PM"),
+	}
+
+	if last := to.AddDate(0, 0, -1); last.Equal(from) {
+		events.RangeLabel = from.Format("Monday, January 2")
 	} else {
-		if cached, expiresAt, found := apiCache.GetWithExpiration("AccessToken"); found {
-			DebugPrint("AccessToken.string:"+cached.(string), DebugLevelVerbose)
-			DebugPrint("AccessToken.expiresAt:"+expiresAt.String(), DebugLevelVerbose)
-			if cached.(string) != "" {
-				authToken = cached.(string)
-			} else {
-				authToken = authenticate()
-			}
-		} else {
-			if cached, expiresAt, found := apiCache.GetWithExpiration("RefreshAccessToken"); found {
-				DebugPrint("RefreshAccessToken.string:"+cached.(string), DebugLevelVerbose)
-				DebugPrint("RefreshAccessToken.expiresAt:"+expiresAt.String(), DebugLevelVerbose)
-				if cached.(string) != "" {
-					authToken = refreshAccessToken(cached.(string))
-				} else {
-					authToken = authenticate()
-				}
-			} else {
-				authToken = authenticate()
-			}
+		events.RangeLabel = from.Format("Mon Jan 2") + " - " + last.Format("Mon Jan 2")
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			events.Days = append(events.Days, ScheduleDay{
+				Date:   day,
+				Label:  day.Format("Monday, January 2"),
+				Groups: map[string][]ScheduleEvent{},
+			})
 		}
 	}
 
-	if authToken == "" {
-		// Replace with alert and retry
-		DebugPrint("Failed to obtain auth token!", DebugLevelErrors)
-		// return "", errors.New("Failed to obtain auth token!")
-	}
-	return authToken
-}
-
-func authenticate() string {
-	authRequest := AuthTokenRequest{
-		ClientID:     os.Getenv("AHWS_CLIENT_ID"),
-		ClientSecret: os.Getenv("AHWS_CLIENT_SECRET"),
-		Username:     os.Getenv("AHWS_USERNAME"),
-		Password:     os.Getenv("AHWS_PASSWORD"),
-		GrantType:    "password",
+	// Day N of M is counted from today, or the first day shown.
+	on := from
+	showingToday := !now.Before(from) && now.Before(to)
+	if showingToday {
+		on = now
 	}
 
-	var authTokenResponse AuthTokenResponse
-
-	// Encode the dictionary as JSON.
-	jsonRequestBody, err := MarshalAndLogWithErrorOutput(authRequest)
-	if err != nil {
-		return ""
-	}
+	for _, event := range result.Events {
+		if !event.IsPosted {
+			continue
+		}
 
-	body := doHTTPRequest(newHTTPPostJSONRequest(kAHWSBaseURL+kAccessTokenPath, jsonRequestBody))
+		start, end, err := parseEventSpan(event, loc)
+		if err != nil {
+			LogError(err)
+			continue
+		}
+		if !start.Before(to) || !end.After(from) {
+			continue
+		}
+		if showingToday && !options.ShowPast && pastGrace(end, now) {
+			continue
+		}
 
-	err = unMarshalAndLogWithErrorOutput(body, &authTokenResponse)
-	if err != nil {
-		return ""
-	} else {
-		apiCache.Set("AuthTokenResponse", authTokenResponse, cache.DefaultExpiration)
-		apiCache.Set("AccessToken", authTokenResponse.AuthToken, cache.DefaultExpiration)
-		apiCache.Set("RefreshAccessToken", authTokenResponse.RefreshToken, time.Hour*71)
+		events.Groups[event.BookingPostAs] = append(events.Groups[event.BookingPostAs], newScheduleEvent(event, start, end, on, now))
+		for _, day := range events.Days {
+			if start.Before(day.Date.AddDate(0, 0, 1)) && end.After(day.Date) {
+				day.Groups[event.BookingPostAs] = append(day.Groups[event.BookingPostAs], newScheduleEvent(event, start, end, day.Date, now))
+			}
+		}
 	}
-	return authTokenResponse.AuthToken
-}
 
-func refreshAccessToken(refreshAccessToken string) string {
-	refreshTokenRequest := RefreshAuthTokenRequest{
-		GrantType:    "refresh_token",
-		RefreshToken: refreshAccessToken,
+	sortScheduleGroups(events.Groups)
+	for _, day := range events.Days {
+		sortScheduleGroups(day.Groups)
 	}
 
-	// Encode the dictionary as JSON.
-	jsonRequestBody, err := json.Marshal(refreshTokenRequest)
-	LogError(err)
-
-	var responseData AuthTokenResponse
-
-	body := doHTTPRequest(newHTTPPostJSONRequest(kAHWSBaseURL+kRefreshAccessTokenPath, jsonRequestBody))
-
-	LogError(json.Unmarshal(body, &responseData))
-	LogPrettyPrintJSON(responseData)
-
-	apiCache.Set("AuthTokenResponse", responseData, cache.DefaultExpiration)
-	apiCache.Set("AccessToken", responseData.AuthToken, cache.DefaultExpiration)
-	apiCache.Set("RefreshAccessToken", responseData.RefreshToken, time.Hour*71)
+	events.Pages = paginateSchedule(events, options.RowsPerPage, options.GroupPerPage)
+	events.PageSeconds = options.PageSeconds
+	return events
+}
 
-	return responseData.AuthToken
+// pastGrace reports whether an event that ends at end finished
+// schedulePastGrace or more before now, and has gone from a board showing
+// today.
+func pastGrace(end time.Time, now time.Time) bool {
+	return now.Sub(end) >= schedulePastGrace
 }
 
-func GetLocationsbyID() ([]LocationResponse, error) {
-	if cacheLevel == CacheLevelAll {
-		cached, expiresAt, found := apiCache.GetWithExpiration("LocationsByID")
-		if found {
-			DebugPrint("hit cache(LocationsByID) - expires at: "+expiresAt.String(), DebugLevelVerbose)
-			//LogPrettyPrintJSON(cached)
-			return cached.([]LocationResponse), nil
-		}
+func newScheduleEvent(event ahws.DefiniteEventSearchResponse, start time.Time, end time.Time, on time.Time, now time.Time) ScheduleEvent {
+	day, days := eventDays(start, end, on)
+	return ScheduleEvent{
+		DefiniteEventSearchResponse: event,
+		Start:                       start,
+		End:                         end,
+		Day:                         day,
+		Days:                        days,
+		Status:                      eventStatus(start, end, now),
+		StartTime:                   eventTimeLabel(start, days),
+		EndTime:                     eventTimeLabel(end, days),
 	}
-	var locationResponse []LocationResponse
-	body := doHTTPRequest(newHTTPGetRequest(kAHWSBaseURL + kLocationsByID))
+}
 
-	err := unMarshalAndLogWithErrorOutput(body, &locationResponse)
-	if len(locationResponse) > 0 && cacheLevel == CacheLevelAll {
-		apiCache.Set("LocationsByID", locationResponse, cache.DefaultExpiration)
+func sortScheduleGroups(groups map[string][]ScheduleEvent) {
+	for _, v := range groups {
+		sort.SliceStable(v, func(i, j int) bool {
+			return v[i].Start.Before(v[j].Start)
+		})
 	}
-	return locationResponse, err
 }
 
-func GetLocationsByExternalID() ([]LocationResponse, error) {
-	if cacheLevel == CacheLevelAll {
-		cached, expiresAt, found := apiCache.GetWithExpiration("LocationsByExternalID")
-		if found {
-			DebugPrint("hit cache(LocationsByExternalID) - expires at: "+expiresAt.String(), DebugLevelVerbose)
-			//LogPrettyPrintJSON(cached)
-			return cached.([]LocationResponse), nil
+// FindRoomInRoomGroups reports whether room is part of the room group that
+// eventRoom is. Room groups are named as events booked into the whole group
+// are, or failing that by its ID. Their rooms may be IDs or names, and are
+// looked up in rooms the same way a cover screen's room is.
+func FindRoomInRoomGroups(rooms functionRoomIndex, room FunctionRoom, eventRoom FunctionRoom) bool {
+	roomGroup, found := lookupRoomGroup(eventRoom.Name)
+	if !found && eventRoom.ID != "" {
+		roomGroup, found = lookupRoomGroup(eventRoom.ID)
+	}
+	if !found {
+		return false
+	}
+	for _, member := range roomGroup.Rooms {
+		if room.same(rooms.resolve(member)) {
+			return true
 		}
 	}
-
-	var locationResponse []LocationResponse
-	body := doHTTPRequest(newHTTPGetRequest(kAHWSBaseURL + kLocationsByExternalID))
-	err := unMarshalAndLogWithErrorOutput(body, &locationResponse)
-
-	if len(locationResponse) > 0 && cacheLevel == CacheLevelAll {
-		apiCache.Set("LocationsByExternalID", locationResponse, cache.DefaultExpiration)
-	}
-	return locationResponse, err
+	return false
 }
 
-func GetFunctionRoomGroup(IDs []string) ([]FunctionRoomGroupsResponse, error) {
-	if len(IDs) == 0 {
-		return nil, errors.New("empty array")
+func coverView(w http.ResponseWriter, cs CoverScreen) {
+	if cs.EventName == "" && !cs.Unavailable && !cs.StartingSoon {
+		cs.EventName = "No Current Event"
 	}
 
-	var IDsToQuery []string
-	var cachedFunctionRoomGroups []FunctionRoomGroupsResponse
-
-	if cacheLevel == CacheLevelAll {
+	screenTemplates.render(w, kCoverTemplate, cs)
+}
 
-		for _, key := range IDs {
-			cached, expiresAt, found := apiCache.GetWithExpiration("FunctionRoomGroup:" + key)
-			if found {
-				DebugPrint("hit cache(FunctionRoomGroup) - expires at: "+expiresAt.String(), DebugLevelVerbose)
-				cachedFunctionRoomGroups = append(cachedFunctionRoomGroups, cached.(FunctionRoomGroupsResponse))
-			} else {
-				IDsToQuery = append(IDsToQuery, key)
-			}
+// buildCoverScreen finds the event currently in progress in roomId, or in a
+// room group containing it, and the next event to start there. roomId is the
+// room's name, or its external function room ID as resolve takes it. When
+// events overlap, policy picks the one shown and the rest are Concurrent.
+func buildCoverScreen(locationID string, roomId string, rooms functionRoomIndex, loc *time.Location, policy CoverPolicy, result ahws.DefiniteEventsResult, eventsErr error) CoverScreen {
+	now := time.Now().In(loc)
+	room := rooms.resolve(roomId)
+	cs := CoverScreen{
+		LocationId:  locationID,
+		RoomId:      roomId,
+		RoomName:    room.label(),
+		TimeZone:    loc.String(),
+		Unavailable: eventsErr != nil,
+		Stale:       result.Stale,
+		FetchedAt:   result.FetchedAt,
+		Version:     screenVersion(result.Events, loc, now, coverBoundaries),
+		LastUpdated: result.FetchedAt.In(loc).Format("03:04 PM"),
+	}
+
+	var current []coverCandidate
+
+	for _, event := range result.Events {
+		eventRoom := eventFunctionRoom(event)
+		if !event.IsPosted || (!room.same(eventRoom) && !FindRoomInRoomGroups(rooms, room, eventRoom)) {
+			continue
 		}
-	} else {
-		IDsToQuery = IDs
-	}
 
-	functionRoomGroupsRequest := FunctionRoomGroupRequest{
-		IDsToQuery,
-	}
-
-	jsonRequestBody, err := MarshalAndLogWithErrorOutput(functionRoomGroupsRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	var functionRoomGroupsResponse []FunctionRoomGroupsResponse
-
-	body := doHTTPRequest(newHTTPPostJSONRequest(kAHWSBaseURL+kFunctionRoomGroupSearchPath, jsonRequestBody))
-
-	err = unMarshalAndLogWithErrorOutput(body, &functionRoomGroupsResponse)
+		start, end, err := parseEventSpan(event, loc)
+		if err != nil {
+			LogError(err)
+			continue
+		}
 
-	if len(functionRoomGroupsResponse) > 0 && cacheLevel == CacheLevelAll {
-		functionRoomGroupsResponse = append(functionRoomGroupsResponse, cachedFunctionRoomGroups...)
-		for _, key := range functionRoomGroupsResponse {
-			apiCache.Add("FunctionRoomGroup:"+key.Id, key, cache.DefaultExpiration)
+		if !start.After(now) && now.Before(end) {
+			current = append(current, coverCandidate{event: event, start: start, end: end})
+		} else if start.After(now) && (cs.Next == nil || start.Before(cs.Next.Start)) {
+			next := newCoverEvent(event, start, end, now)
+			cs.Next = &next
 		}
 	}
-	return functionRoomGroupsResponse, err
-}
 
-// kFunctionRoomsSearchPath
-func GetFunctionRooms(req FunctionRoomRequest) []any {
-	jsonRequestBody, err := json.Marshal(req)
-	LogError(err)
-
-	body := doHTTPRequest(newHTTPPostJSONRequest(kAHWSBaseURL+"/api/V2/FunctionRoom/Export", jsonRequestBody))
-	fmtData := &bytes.Buffer{}
-	json.Indent(fmtData, body, "", " ")
-	fmt.Println(fmtData.String())
-
-	return nil
-}
+	sortCoverCandidates(current, policy)
+	for i, candidate := range current {
+		if i > 0 {
+			cs.Concurrent = append(cs.Concurrent, newCoverEvent(candidate.event, candidate.start, candidate.end, now))
+			continue
+		}
 
-func DebugPrint(s any, logLevel int) {
-	if debugLevel >= logLevel {
-		log.Println("DEBUG:", s)
+		start, end := candidate.start, candidate.end
+		cs.EventName = candidate.event.Name
+		cs.Start, cs.End = &start, &end
+		cs.Day, cs.Days = eventDays(start, end, now)
+		cs.StartTime = eventTimeLabel(start, cs.Days)
+		cs.EndTime = eventTimeLabel(end, cs.Days)
+		if cs.Days == 1 {
+			cs.MinutesRemaining = int(math.Ceil(end.Sub(now).Minutes()))
+		}
 	}
-}
 
-func GetBookingEventDetails(definiteEventSearchRequest DefiniteEventSearchRequest) ([]DefiniteEventSearchResponse, error) {
-	var cachedEvents []DefiniteEventSearchResponse
-	var definiteEventSearchResponse []DefiniteEventSearchResponse
-	var shouldQuery bool
-	var err error
+	cs.StartingSoon = cs.EventName == "" && cs.Next != nil && cs.Next.Start.Sub(now) <= startingSoonWindow
 
-	eventRange := definiteEventSearchRequest.BookingEventDateTimeBegin + ":to:" + definiteEventSearchRequest.BookingEventDateTimeEnd
-
-	if cacheLevel == CacheLevelAll {
-		cached, expiresAt, found := apiCache.GetWithExpiration("BookingEventsDetailsWithInDateRange:" + eventRange + ":AtLocation:" + definiteEventSearchRequest.LocationId)
-		if found {
-			shouldQuery = false
-			cachedEvents = cached.([]DefiniteEventSearchResponse)
-			DebugPrint("hit cache(BookingEventsDetailsWithInDateRange) - expires at: "+expiresAt.String(), DebugLevelVerbose)
-			//LogPrettyPrintJSON(cachedEvents)
-			return cachedEvents, nil
-		} else {
-			shouldQuery = true
+	changes := func(at time.Time) {
+		if at.After(now) && (cs.ChangesAt == nil || at.Before(*cs.ChangesAt)) {
+			cs.ChangesAt = &at
 		}
 	}
-	if shouldQuery {
-		jsonRequestBody, err := MarshalAndLogWithErrorOutput(definiteEventSearchRequest)
-		if err != nil {
-			return nil, err
+	var busyUntil time.Time
+	for _, candidate := range current {
+		changes(candidate.end)
+		if candidate.end.After(busyUntil) {
+			busyUntil = candidate.end
 		}
-
-		body := doHTTPRequest(newHTTPPostJSONRequest(kAHWSBaseURL+kDefiniteEventSearchPath, jsonRequestBody))
-		err = unMarshalAndLogWithErrorOutput(body, &definiteEventSearchResponse)
-		if len(definiteEventSearchResponse) > 0 && cacheLevel == CacheLevelAll {
-			apiCache.Set("BookingEventsDetailsWithInDateRange:"+eventRange+":AtLocation:"+definiteEventSearchRequest.LocationId, definiteEventSearchResponse, cache.DefaultExpiration)
+	}
+	if cs.Next != nil {
+		// Starting soon only shows once the room is free.
+		if soon := cs.Next.Start.Add(-startingSoonWindow); !busyUntil.After(soon) {
+			changes(soon)
 		}
-		return definiteEventSearchResponse, err
+		changes(cs.Next.Start)
 	}
-	return definiteEventSearchResponse, err
+	return cs
 }
 
-func MarshalAndLogWithErrorOutput(request interface{}) (jsonRequestBody []byte, err error) {
-	pc, _, _, _ := runtime.Caller(1)
-	callerMethod := runtime.FuncForPC(pc).Name()
-
-	DebugPrint("Marshing type:"+TypeName(request)+" - from function:"+callerMethod, DebugLevelVerbose)
-
-	jsonRequestBody, err = json.Marshal(request)
-
-	if err != nil {
-		DebugPrint(err, DebugLevelErrors)
-	} else {
-		//LogPrettyPrintJSON(request)
+func newCoverEvent(event ahws.DefiniteEventSearchResponse, start time.Time, end time.Time, now time.Time) CoverEvent {
+	day, days := eventDays(start, end, now)
+	return CoverEvent{
+		Name:      event.Name,
+		Room:      event.FunctionRoomName,
+		Start:     start,
+		End:       end,
+		Day:       day,
+		Days:      days,
+		StartTime: eventTimeLabel(start, days),
+		EndTime:   eventTimeLabel(end, days),
 	}
-	return jsonRequestBody, err
 }
-func unMarshalAndLogWithErrorOutput(body []byte, request interface{}) (err error) {
-	pc, _, _, _ := runtime.Caller(1)
-	callerMethod := runtime.FuncForPC(pc).Name()
-	DebugPrint("UnMarshing type:"+TypeName(request)+" - from function:"+callerMethod, DebugLevelVerbose)
 
-	json.Unmarshal(body, &request)
+// todaysEventSearch searches from today until tomorrow at the location.
+func todaysEventSearch(locationID string, groupID string, loc *time.Location) ahws.DefiniteEventSearchRequest {
+	today := startOfDay(time.Now().In(loc))
+	return eventSearch(locationID, groupID, today, today.AddDate(0, 0, 1))
+}
 
-	if err != nil {
-		DebugPrint(err, DebugLevelErrors)
-	} else {
-		//LogPrettyPrintJSON(request)
+// eventSearch searches for events overlapping the dates from until to,
+// starting eventSearchLookBackDays early for events that began before from.
+func eventSearch(locationID string, groupID string, from time.Time, to time.Time) ahws.DefiniteEventSearchRequest {
+	return ahws.DefiniteEventSearchRequest{
+		LocationId:                locationID,
+		FunctionRoomGroupId:       groupID,
+		BookingEventDateTimeBegin: from.AddDate(0, 0, -eventSearchLookBackDays).Format("2006-01-02"),
+		BookingEventDateTimeEnd:   to.Format("2006-01-02"),
 	}
-	return err
 }
 
-func LogPrettyPrintJSON(data interface{}) {
-	log.Println(PrettyPrintJSON(data))
-}
+func httpServer(cancelChan chan<- os.Signal) {
+	http.HandleFunc("/view/cover", func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("location-id") || !r.URL.Query().Has("room-id") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("location-id, and room-id must be provided"))
+			return
+		}
 
-func PrettyPrintJSON(data interface{}) string {
-	b, err := json.MarshalIndent(data, "", "    ")
+		policy, showConcurrent, err := coverOptions(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
 
-	// Marshal the map back into a JSON byte slice with indentation
-	if err != nil {
-		log.Println(err)
-		return ""
-	}
-	return (string(b))
-}
+		loc := LocationTimeZone(r.Context(), r.URL.Query().Get("location-id"))
+		result, err := apiClient.SearchDefiniteEvents(r.Context(),
+			todaysEventSearch(r.URL.Query().Get("location-id"), "", loc))
+		LogError(err)
+		rooms := loadFunctionRooms(r.Context(), r.URL.Query().Get("location-id"))
+		cs := buildCoverScreen(r.URL.Query().Get("location-id"), r.URL.Query().Get("room-id"), rooms, loc, policy, result, err)
+		cs.ShowConcurrent = showConcurrent
+		coverView(w, cs)
+	})
 
-func LogError(err error) {
-	if err != nil && debugLevel >= DebugLevelErrors {
-		log.Println(err)
-		return
-	}
-}
+	http.HandleFunc("/view/schedule", func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("location-id") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("location-id must be provided"))
+			return
+		}
 
-func newHTTPGetRequest(URI string) (req *http.Request) {
-	return newHTTPRequest("GET", URI, nil)
-}
+		loc := LocationTimeZone(r.Context(), r.URL.Query().Get("location-id"))
+		options, err := scheduleOptions(r.URL.Query(), loc)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
 
-func newHTTPPostJSONRequest(URI string, json []byte) (req *http.Request) {
-	return newHTTPRequest("POST", URI, json)
-}
+		result, err := apiClient.SearchDefiniteEvents(r.Context(),
+			eventSearch(r.URL.Query().Get("location-id"), r.URL.Query().Get("group-id"), options.From, options.To))
+		LogError(err)
+		scheduleView(w, buildScheduleScreen(r.URL.Query().Get("location-id"), loc, options, result, err))
+	})
 
-func newHTTPRequest(requestType string, URI string, json []byte) (req *http.Request) {
-	req, err := http.NewRequest(requestType, URI, bytes.NewReader(json))
-	if err != nil {
-		DebugPrint(err, DebugLevelErrors)
-		return
-	}
+	http.HandleFunc("/events/cover", func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("location-id") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("location-id must be provided"))
+			return
+		}
 
-	req.Header.Set("Ocp-Apim-Subscription-Key", ocpApimSubscriptionKey)
+		loc := LocationTimeZone(r.Context(), r.URL.Query().Get("location-id"))
+		locationID := r.URL.Query().Get("location-id")
+		serveScreenUpdates(w, r, "cover:"+locationID, loc, coverBoundaries, func() ahws.DefiniteEventSearchRequest {
+			return todaysEventSearch(locationID, "", loc)
+		})
+	})
 
-	if json != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	http.HandleFunc("/events/schedule", func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("location-id") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("location-id must be provided"))
+			return
+		}
 
-	if strings.Contains(req.URL.Path, kAPIPath) {
-		DebugPrint(URI, DebugLevelVerbose)
-		req.Header.Set("Authorization", "OAuth "+GetAuthToken())
-	}
+		loc := LocationTimeZone(r.Context(), r.URL.Query().Get("location-id"))
+		query := r.URL.Query()
+		if _, err := scheduleOptions(query, loc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		// The dates are read again each poll, so today moves on at midnight.
+		key := "schedule:" + query.Get("location-id") + "|" + query.Get("group-id") + "|" +
+			query.Get("date") + "|" + query.Get("days") + "|" + query.Get("from") + "|" + query.Get("to")
+		serveScreenUpdates(w, r, key, loc, scheduleBoundaries, func() ahws.DefiniteEventSearchRequest {
+			// The query was checked above, the dates can't fail now.
+			options, _ := scheduleOptions(query, loc)
+			return eventSearch(query.Get("location-id"), query.Get("group-id"), options.From, options.To)
+		})
+	})
 
-	return req
-}
+	http.HandleFunc(kAPILocationsPath, apiLocations)
+	http.HandleFunc(kAdminRoomGroupsPath, adminRoomGroups)
+	http.HandleFunc(kAdminRoomGroupsPath+"/", adminRoomGroups)
+	http.HandleFunc(kAdminMappingCheckPath, adminMappingCheck)
 
-func httpDo(ctx context.Context, req *http.Request, f func(*http.Response, error) error) error {
-	// Run the HTTP request in a goroutine and pass the response to f.
-	c := make(chan error, 1)
-	req = req.WithContext(ctx)
-	go func() { c <- f(http.DefaultClient.Do(req)) }()
-	select {
-	case <-ctx.Done():
-		<-c // Wait for f to return.
-		return ctx.Err()
-	case err := <-c:
-		return err
+	port, ok := os.LookupEnv("PORT")
+	if !ok {
+		port = "8080"
 	}
-}
 
-func doHTTPRequest(req *http.Request) (body []byte) {
-	body, err := DoHTTPRequest(req)
-	DebugPrint(err, DebugLevelErrors)
-	return body
+	http.ListenAndServe(":"+port, nil)
 }
 
-func DoHTTPRequest(req *http.Request) (body []byte, err error) {
-	DebugPrint(req.URL, DebugLevelVerbose)
-	req = req.WithContext(context.Background())
-
-	err = httpDo(context.Background(), req, func(resp *http.Response, err error) error {
-		if err != nil {
-			DebugPrint(err, DebugLevelErrors)
-			return err
-		} else {
-			pc, _, _, _ := runtime.Caller(1)
-			callerMethod := runtime.FuncForPC(pc).Name()
-			DebugPrint("HTTP code:"+resp.Status+" - in function:"+callerMethod, DebugLevelVerbose)
-			if resp.StatusCode != 200 {
-				DebugPrint("HTTP code:"+resp.Status+" - in function:"+callerMethod, DebugLevelErrors)
-			}
-			defer resp.Body.Close()
-			body, err = io.ReadAll(resp.Body)
-			if err != nil {
-				DebugPrint(err, DebugLevelErrors)
-				return err
-			}
-			if debugLevel == DebugLevelTrace {
-				log.Print("request header")
-				LogPrettyPrintJSON(req.Header)
-
-				log.Print("request body:")
-				LogPrettyPrintJSON(req.Body)
-
-				log.Print("response header")
-				LogPrettyPrintJSON(resp.Header)
-
-				log.Print("response body:")
-				LogPrettyPrintJSON(resp.Body)
-			}
+// snapshotter saves the cache every interval, so a crash loses at most
+// interval worth of cached responses.
+func snapshotter(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			saveCacheGob()
 		}
-		return err
-	})
-	return body, err
+	}
 }
 
 func saveCacheGob() {
-	if cacheLevel != CacheLevelNone {
-		DebugPrint("saving cache", DebugLevelVerbose)
-		cachedItems := apiCache.Items()
-
-		// Create a file to store the gob
-		file, err := os.Create("cache.gob")
-		if err != nil {
-			DebugPrint(err, DebugLevelErrors)
+	if cacheLevel != ahws.CacheLevelNone {
+		memoryCache, ok := apiCache.(*cachestore.Memory)
+		if !ok {
+			// Shared backends persist themselves.
+			return
 		}
-		defer file.Close()
-
-		// Create a gob encoder
-		encoder := gob.NewEncoder(file)
-
-		// Encode the map
-		err = encoder.Encode(cachedItems)
-		if err != nil {
-			DebugPrint(err, DebugLevelErrors)
+		DebugPrint("saving cache to "+snapshotPath, DebugLevelVerbose)
+		if err := cachestore.SaveSnapshot(memoryCache, snapshotPath, snapshotSchema); err != nil {
+			DebugPrint("saving cache failed: "+err.Error(), DebugLevelErrors)
+			return
 		}
 		DebugPrint("saved cache", DebugLevelVerbose)
 	}
 }
 
 func loadCacheGob() {
-	if cacheLevel != CacheLevelNone {
-		DebugPrint("loading cache", DebugLevelVerbose)
-		file, err := os.Open("cache.gob")
-		if err != nil {
-			DebugPrint(err, DebugLevelErrors)
+	if _, ok := apiCache.(*cachestore.Memory); !ok {
+		// Shared backends persist themselves.
+		return
+	}
+	if cacheLevel != ahws.CacheLevelNone {
+		DebugPrint("loading cache from "+snapshotPath, DebugLevelVerbose)
+		items, err := cachestore.LoadSnapshot(snapshotPath, snapshotSchema)
+		if errors.Is(err, os.ErrNotExist) {
+			DebugPrint("no cache snapshot, starting empty", DebugLevelVerbose)
+			return
 		}
-		defer file.Close()
-
-		// Create a gob decoder
-		decoder := gob.NewDecoder(file)
-
-		// Decode the gob into a map
-		var items map[string]cache.Item
-		err = decoder.Decode(&items)
-		if err == nil {
-			apiCache = cache.NewFrom(15*time.Minute, 20*time.Minute, items)
-		} else {
-			DebugPrint(err, DebugLevelErrors)
+		if err != nil {
+			DebugPrint("rejecting cache snapshot: "+err.Error(), DebugLevelErrors)
+			quarantined, err := cachestore.QuarantineSnapshot(snapshotPath)
+			if err != nil {
+				LogError(err)
+			} else {
+				DebugPrint("moved cache snapshot to "+quarantined, DebugLevelErrors)
+			}
+			return
 		}
+		apiCache = cachestore.NewMemoryFrom(15*time.Minute, 20*time.Minute, items)
 		DebugPrint("loaded cache", DebugLevelVerbose)
 	}
 }
-
-func loadJSONMapping() ([]RoomGroups, error) {
-	// Open the JSON file
-	file, err := os.Open("mapping.json")
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	defer file.Close()
-
-	// Decode the JSON data into a struct
-	var roomGroups []RoomGroups
-	err = json.NewDecoder(file).Decode(&roomGroups)
-
-	for _, roomGroup := range roomGroups {
-		apiCache.Set("RoomGroupByGroupName:"+roomGroup.RoomGroup, roomGroup, cache.NoExpiration)
-	}
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	} else {
-		return roomGroups, nil
-	}
-
-}
-
-func TypeName(t any) (x string) {
-	n := reflect.TypeOf(t).Name()
-	if n == "" {
-		return reflect.TypeOf(t).Elem().String()
-	} else {
-		return n
-	}
-}
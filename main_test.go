@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/v2/ahws"
+	"example.com/m/v2/cachestore"
+)
+
+func testEvent(name string, room FunctionRoom, start time.Time, end time.Time) ahws.DefiniteEventSearchResponse {
+	return ahws.DefiniteEventSearchResponse{
+		Name:                   name,
+		FunctionRoomName:       room.Name,
+		ExternalFunctionRoomId: room.ID,
+		StartDateTime:          start.Format("2006-01-02T15:04:05"),
+		EndDateTime:            end.Format("2006-01-02T15:04:05"),
+		IsPosted:               true,
+		BookingPostAs:          "Host",
+	}
+}
+
+func TestEventSearchLooksBack(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2024, time.March, 10, 0, 0, 0, 0, loc)
+	search := eventSearch("LOC1", "G1", from, from.AddDate(0, 0, 1))
+	if search.BookingEventDateTimeBegin != "2024-03-07" || search.BookingEventDateTimeEnd != "2024-03-11" {
+		t.Errorf("searched %s to %s, want 2024-03-07 to 2024-03-11",
+			search.BookingEventDateTimeBegin, search.BookingEventDateTimeEnd)
+	}
+
+	today := startOfDay(time.Now().In(loc))
+	if got, want := todaysEventSearch("LOC1", "", loc).BookingEventDateTimeBegin, today.AddDate(0, 0, -eventSearchLookBackDays).Format("2006-01-02"); got != want {
+		t.Errorf("today's search starts %s, want %s", got, want)
+	}
+}
+
+// Events that started on an earlier day are found by the wider search, and
+// the screens still only show the ones running on the days they cover.
+func TestScreensFilterBySpan(t *testing.T) {
+	loc := time.UTC
+	now := time.Now().In(loc)
+	today := startOfDay(now)
+	room := FunctionRoom{ID: "E3", Name: "Boardroom"}
+	result := ahws.DefiniteEventsResult{Events: []ahws.DefiniteEventSearchResponse{
+		testEvent("Expo", room, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1)),
+		testEvent("Last Week", room, today.AddDate(0, 0, -3), today.AddDate(0, 0, -2)),
+	}}
+
+	cs := buildCoverScreen("LOC1", "E3", testFunctionRooms(room), loc, CoverPolicyStart, result, nil)
+	if cs.EventName != "Expo" || cs.Day != 2 || cs.Days != 2 {
+		t.Errorf("cover shows %q day %d of %d, want Expo day 2 of 2", cs.EventName, cs.Day, cs.Days)
+	}
+
+	schedule := buildScheduleScreen("LOC1", loc, ScheduleOptions{From: today, To: today.AddDate(0, 0, 1)}, result, nil)
+	events := schedule.Groups["Host"]
+	if len(events) != 1 || events[0].Name != "Expo" {
+		t.Errorf("schedule shows %v, want only Expo", events)
+	}
+}
+
+// A corrupt snapshot is moved aside and the cache starts empty, rather than
+// stopping startup.
+func TestLoadCacheGobQuarantinesCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, oldCache := snapshotPath, apiCache
+	t.Cleanup(func() { snapshotPath, apiCache = oldPath, oldCache })
+
+	snapshotPath = filepath.Join(dir, "cache.gob")
+	apiCache = cachestore.NewMemory(time.Minute, time.Minute)
+	if err := os.WriteFile(snapshotPath, []byte("not a snapshot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadCacheGob()
+
+	if _, err := os.Stat(snapshotPath); err == nil {
+		t.Error("corrupt snapshot wasn't moved")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "cache.gob.bad-") {
+		t.Errorf("got %v, want the quarantined snapshot", entries)
+	}
+	if memory, ok := apiCache.(*cachestore.Memory); !ok || len(memory.Keys()) != 0 {
+		t.Error("cache isn't empty after rejecting the snapshot")
+	}
+}
+
+// middayZone is a time zone where it's around midday now, so events either
+// side of now don't cross midnight.
+func middayZone() *time.Location {
+	now := time.Now().UTC()
+	return time.FixedZone("Midday", 12*60*60-(now.Hour()*60*60+now.Minute()*60))
+}
+
+func TestBuildCoverScreen(t *testing.T) {
+	loc := middayZone()
+	base := time.Now().In(loc).Truncate(time.Minute)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	room := FunctionRoom{ID: "E3", Name: "Boardroom"}
+	if startingSoonWindow != 15*time.Minute {
+		t.Fatalf("startingSoonWindow is %v, the cases expect 15m", startingSoonWindow)
+	}
+
+	tests := []struct {
+		name             string
+		events           []ahws.DefiniteEventSearchResponse
+		eventName        string
+		next             string
+		startingSoon     bool
+		minutesRemaining int
+		changesAt        *time.Time
+	}{
+		{name: "empty room"},
+		{
+			name:             "current event",
+			events:           []ahws.DefiniteEventSearchResponse{testEvent("Lunch", room, at(-30), at(30))},
+			eventName:        "Lunch",
+			minutesRemaining: 30,
+			changesAt:        timePtr(at(30)),
+		},
+		{
+			name:         "next event starting soon",
+			events:       []ahws.DefiniteEventSearchResponse{testEvent("Keynote", room, at(10), at(70))},
+			next:         "Keynote",
+			startingSoon: true,
+			changesAt:    timePtr(at(10)),
+		},
+		{
+			name:      "next event outside the starting soon window",
+			events:    []ahws.DefiniteEventSearchResponse{testEvent("Keynote", room, at(60), at(120))},
+			next:      "Keynote",
+			changesAt: timePtr(at(45)),
+		},
+		{
+			name: "back to back",
+			events: []ahws.DefiniteEventSearchResponse{
+				testEvent("Lunch", room, at(-30), at(30)),
+				testEvent("Keynote", room, at(30), at(90)),
+			},
+			eventName:        "Lunch",
+			next:             "Keynote",
+			minutesRemaining: 30,
+			changesAt:        timePtr(at(30)),
+		},
+	}
+	for _, test := range tests {
+		result := ahws.DefiniteEventsResult{Events: test.events}
+		cs := buildCoverScreen("LOC1", "E3", testFunctionRooms(room), loc, CoverPolicyStart, result, nil)
+
+		next := ""
+		if cs.Next != nil {
+			next = cs.Next.Name
+		}
+		if cs.EventName != test.eventName || next != test.next {
+			t.Errorf("%s: showing %q next %q, want %q next %q", test.name, cs.EventName, next, test.eventName, test.next)
+		}
+		if cs.StartingSoon != test.startingSoon {
+			t.Errorf("%s: StartingSoon = %v, want %v", test.name, cs.StartingSoon, test.startingSoon)
+		}
+		if cs.MinutesRemaining != test.minutesRemaining {
+			t.Errorf("%s: MinutesRemaining = %d, want %d", test.name, cs.MinutesRemaining, test.minutesRemaining)
+		}
+		switch {
+		case test.changesAt == nil && cs.ChangesAt != nil:
+			t.Errorf("%s: ChangesAt = %v, want nil", test.name, cs.ChangesAt)
+		case test.changesAt != nil && (cs.ChangesAt == nil || !cs.ChangesAt.Equal(*test.changesAt)):
+			t.Errorf("%s: ChangesAt = %v, want %v", test.name, cs.ChangesAt, test.changesAt)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestBuildScheduleScreenStatus(t *testing.T) {
+	loc := middayZone()
+	base := time.Now().In(loc).Truncate(time.Minute)
+	at := func(minutes time.Duration) time.Time { return base.Add(minutes * time.Minute) }
+	grace := schedulePastGrace / time.Minute
+	room := FunctionRoom{ID: "E3", Name: "Boardroom"}
+	result := ahws.DefiniteEventsResult{Events: []ahws.DefiniteEventSearchResponse{
+		testEvent("Breakfast", room, at(-120), at(-grace-1)),
+		testEvent("Coffee", room, at(-60), at(-grace+1)),
+		testEvent("Keynote", room, at(-30), at(30)),
+		testEvent("Lunch", room, at(30), at(90)),
+	}}
+	today := startOfDay(base)
+
+	for _, showPast := range []bool{false, true} {
+		options := ScheduleOptions{From: today, To: today.AddDate(0, 0, 1), ShowPast: showPast}
+		schedule := buildScheduleScreen("LOC1", loc, options, result, nil)
+
+		got := map[string]EventStatus{}
+		for _, event := range schedule.Groups["Host"] {
+			got[event.Name] = event.Status
+		}
+		want := map[string]EventStatus{
+			"Coffee":  EventPast,
+			"Keynote": EventLive,
+			"Lunch":   EventUpcoming,
+		}
+		if showPast {
+			want["Breakfast"] = EventPast
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("show past %v: got %v, want %v", showPast, got, want)
+		}
+	}
+}
+
+func TestPastGrace(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		end  time.Time
+		gone bool
+	}{
+		{now.Add(time.Minute), false},
+		{now, false},
+		{now.Add(-schedulePastGrace + time.Second), false},
+		{now.Add(-schedulePastGrace), true},
+		{now.Add(-schedulePastGrace - time.Second), true},
+	}
+	for _, test := range tests {
+		if got := pastGrace(test.end, now); got != test.gone {
+			t.Errorf("ended %v before now: pastGrace = %v, want %v", now.Sub(test.end), got, test.gone)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/v2/cachestore"
+)
+
+var (
+	mappingPath      = "mapping.json"
+	mappingAuditPath = "mapping-audit.log"
+
+	// mappingMu serializes changes to the mapping file.
+	mappingMu sync.Mutex
+	// fileMapping is the room groups last read from the mapping file.
+	// Guarded by mappingMu.
+	fileMapping []RoomGroups
+
+	// activeRoomGroups is the room groups FindRoomInRoomGroups uses, by name.
+	// They're kept in this process rather than apiCache: with a shared cache
+	// each replica's mapping would replace the others'. Guarded by
+	// activeRoomGroupsMu.
+	activeRoomGroups   = map[string]RoomGroups{}
+	activeRoomGroupsMu sync.RWMutex
+)
+
+// MappingAuditEntry records one change to the room group mapping.
+type MappingAuditEntry struct {
+	Time       time.Time
+	User       string
+	RemoteAddr string
+	Action     string
+	RoomGroup  string
+	Before     *RoomGroups `json:",omitempty"`
+	After      *RoomGroups `json:",omitempty"`
+}
+
+// readJSONMapping reads and validates the room groups in path. A missing file
+// is an empty mapping.
+func readJSONMapping(path string) ([]RoomGroups, error) {
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var roomGroups []RoomGroups
+	if err := json.Unmarshal(body, &roomGroups); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := validateRoomGroups(roomGroups); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return roomGroups, nil
+}
+
+// validateRoomGroups checks every group has a unique name and at least one
+// room, without blanks or duplicates.
+func validateRoomGroups(roomGroups []RoomGroups) error {
+	names := map[string]bool{}
+	for i, roomGroup := range roomGroups {
+		if err := validateRoomGroup(roomGroup); err != nil {
+			return fmt.Errorf("room group %s: %v", roomGroupLabel(i, roomGroup), err)
+		}
+		if names[roomGroup.RoomGroup] {
+			return fmt.Errorf("room group %s is duplicated", roomGroupLabel(i, roomGroup))
+		}
+		names[roomGroup.RoomGroup] = true
+	}
+	return nil
+}
+
+func validateRoomGroup(roomGroup RoomGroups) error {
+	if strings.TrimSpace(roomGroup.RoomGroup) == "" {
+		return errors.New("RoomGroup must be provided")
+	}
+	if roomGroup.RoomGroup != strings.TrimSpace(roomGroup.RoomGroup) {
+		return errors.New("RoomGroup has leading or trailing spaces")
+	}
+	if len(roomGroup.Rooms) == 0 {
+		return errors.New("Rooms must not be empty")
+	}
+	rooms := map[string]bool{}
+	for _, room := range roomGroup.Rooms {
+		if strings.TrimSpace(room) == "" {
+			return errors.New("Rooms must not contain blank names")
+		}
+		if rooms[room] {
+			return errors.New("room " + room + " is listed twice")
+		}
+		rooms[room] = true
+	}
+	return nil
+}
+
+func roomGroupLabel(i int, roomGroup RoomGroups) string {
+	if roomGroup.RoomGroup != "" {
+		return `"` + roomGroup.RoomGroup + `"`
+	}
+	return "#" + strconv.Itoa(i+1)
+}
+
+// writeJSONMapping replaces the mapping file with WriteFileAtomic.
+func writeJSONMapping(path string, roomGroups []RoomGroups) error {
+	if roomGroups == nil {
+		roomGroups = []RoomGroups{}
+	}
+	body, err := json.MarshalIndent(roomGroups, "", "    ")
+	if err != nil {
+		return err
+	}
+	return cachestore.WriteFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(append(body, '\n'))
+		return err
+	})
+}
+
+// applyMapping makes roomGroups from the mapping file, on top of any generated
+// from AHWS, the ones FindRoomInRoomGroups uses, replacing them all at once.
+// mappingMu must be held.
+func applyMapping(roomGroups []RoomGroups) {
+	fileMapping = roomGroups
+	active := map[string]RoomGroups{}
+	for _, roomGroup := range mergeMapping(generatedMapping, roomGroups) {
+		active[roomGroup.RoomGroup] = roomGroup
+	}
+
+	activeRoomGroupsMu.Lock()
+	activeRoomGroups = active
+	activeRoomGroupsMu.Unlock()
+}
+
+// lookupRoomGroup returns the room group in effect named name.
+func lookupRoomGroup(name string) (RoomGroups, bool) {
+	activeRoomGroupsMu.RLock()
+	defer activeRoomGroupsMu.RUnlock()
+	roomGroup, found := activeRoomGroups[name]
+	return roomGroup, found
+}
+
+// updateMapping applies change to the room groups in the mapping file, and if
+// the result is valid saves it, puts it into effect and records it in the
+// audit log.
+func updateMapping(entry *MappingAuditEntry, change func([]RoomGroups) ([]RoomGroups, error)) error {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+
+	roomGroups, err := readJSONMapping(mappingPath)
+	if err != nil {
+		return err
+	}
+	roomGroups, err = change(roomGroups)
+	if err != nil {
+		return err
+	}
+	if err := validateRoomGroups(roomGroups); err != nil {
+		return err
+	}
+	if err := writeJSONMapping(mappingPath, roomGroups); err != nil {
+		return err
+	}
+	applyMapping(roomGroups)
+
+	entry.Time = time.Now()
+	LogError(appendMappingAudit(*entry))
+	DebugPrint(entry.User+" "+entry.Action+" room group "+entry.RoomGroup, DebugLevelErrors)
+	return nil
+}
+
+func appendMappingAudit(entry MappingAuditEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(mappingAuditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(body, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// reloadMapping reads the mapping file again and puts it into effect. A
+// malformed file is rejected, and the last good mapping stays in use. A
+// missing file is an empty mapping, as it is to the admin API. The file is
+// read and checked as a whole before anything changes, then the room groups
+// are swapped at once, so FindRoomInRoomGroups never misses a group in both
+// the old and new mapping.
+func reloadMapping() error {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+
+	if _, err := os.Stat(mappingPath); errors.Is(err, os.ErrNotExist) {
+		DebugPrint(mappingPath+" not found, no room groups are mapped", DebugLevelErrors)
+	}
+	roomGroups, err := readJSONMapping(mappingPath)
+	if err != nil {
+		return fmt.Errorf("keeping the last good mapping: %v", err)
+	}
+	applyMapping(roomGroups)
+	DebugPrint("loaded "+strconv.Itoa(len(roomGroups))+" room groups from "+mappingPath, DebugLevelVerbose)
+	return nil
+}
+
+// mappingWatcher reloads the mapping file whenever it changes, checking every
+// interval.
+func mappingWatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	lastModified := mappingModified()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modified := mappingModified()
+		if modified == lastModified {
+			continue
+		}
+		lastModified = modified
+		DebugPrint(mappingPath+" changed, reloading", DebugLevelVerbose)
+		LogError(reloadMapping())
+	}
+}
+
+// mappingModified identifies the version of the mapping file on disk.
+func mappingModified() string {
+	info, err := os.Stat(mappingPath)
+	if err != nil {
+		return ""
+	}
+	return info.ModTime().String() + "/" + strconv.FormatInt(info.Size(), 10)
+}
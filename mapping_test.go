@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example.com/m/v2/cachestore"
+)
+
+// Room groups stay in the process, so replicas sharing a cache don't replace
+// each other's mapping.
+func TestApplyMappingKeepsRoomGroupsLocal(t *testing.T) {
+	saved := apiCache
+	apiCache = cachestore.NewMemory(time.Minute, time.Minute)
+	defer func() { apiCache = saved }()
+
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	defer applyMapping(nil)
+
+	ballroom := FunctionRoom{Name: "Ballroom A"}
+	combined := FunctionRoom{Name: "Ballroom"}
+
+	applyMapping([]RoomGroups{{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A", "Ballroom B"}}})
+	if !FindRoomInRoomGroups(functionRoomIndex{}, ballroom, combined) {
+		t.Error("Ballroom A isn't in the Ballroom group")
+	}
+	if keys := apiCache.Keys(); len(keys) != 0 {
+		t.Errorf("room groups were written to the cache: %v", keys)
+	}
+
+	applyMapping([]RoomGroups{{RoomGroup: "Salon", Rooms: []string{"Salon 1"}}})
+	if FindRoomInRoomGroups(functionRoomIndex{}, ballroom, combined) {
+		t.Error("the Ballroom group is still in effect after it was removed")
+	}
+}
+
+// A missing mapping file is an empty mapping when reloading, as it is to the
+// admin API, and a malformed one keeps the last good mapping.
+func TestReloadMapping(t *testing.T) {
+	saved := mappingPath
+	mappingPath = filepath.Join(t.TempDir(), "mapping.json")
+	defer func() { mappingPath = saved }()
+	defer func() {
+		mappingMu.Lock()
+		applyMapping(nil)
+		mappingMu.Unlock()
+	}()
+
+	ballroom := FunctionRoom{Name: "Ballroom A"}
+	combined := FunctionRoom{Name: "Ballroom"}
+
+	if err := writeJSONMapping(mappingPath, []RoomGroups{{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadMapping(); err != nil || !FindRoomInRoomGroups(functionRoomIndex{}, ballroom, combined) {
+		t.Fatalf("mapping wasn't loaded: %v", err)
+	}
+
+	if err := os.WriteFile(mappingPath, []byte("[{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadMapping(); err == nil || !FindRoomInRoomGroups(functionRoomIndex{}, ballroom, combined) {
+		t.Errorf("got %v, want the malformed file rejected and the last good mapping kept", err)
+	}
+
+	if err := os.Remove(mappingPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadMapping(); err != nil {
+		t.Errorf("got %v for a missing file, want an empty mapping", err)
+	}
+	if FindRoomInRoomGroups(functionRoomIndex{}, ballroom, combined) {
+		t.Error("room groups are still mapped after the file was removed")
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type (
+	// MappingReport is what's wrong with the mapping file for a location,
+	// checked against its function rooms and function room groups in AHWS.
+	MappingReport struct {
+		LocationId string
+		Valid      bool
+		// UnknownRooms are names or IDs in the mapping that match no function
+		// room or function room group at the location.
+		UnknownRooms []UnknownMappingRoom
+		// UngroupedRooms are function rooms in neither a mapping room group
+		// nor an AHWS function room group.
+		UngroupedRooms []string
+	}
+
+	UnknownMappingRoom struct {
+		RoomGroup string
+		// Room is empty when it's the RoomGroup name that is unknown.
+		Room string `json:",omitempty"`
+		// NearMisses are known names that differ only in case or whitespace,
+		// or by a couple of characters.
+		NearMisses []string `json:",omitempty"`
+	}
+)
+
+// checkMapping compares roomGroups with the function rooms and function room
+// groups at locationID. Event room names must match the mapping exactly, so a
+// typo there means a cover screen never shows the group's events.
+func checkMapping(ctx context.Context, locationID string, roomGroups []RoomGroups) (MappingReport, error) {
+	rooms, err := apiClient.GetFunctionRoomsAtLocation(ctx, locationID)
+	if err != nil {
+		return MappingReport{}, err
+	}
+	groups, err := apiClient.GetFunctionRoomGroup(ctx, []string{locationID})
+	if err != nil {
+		return MappingReport{}, err
+	}
+
+	report := MappingReport{LocationId: locationID, UnknownRooms: []UnknownMappingRoom{}, UngroupedRooms: []string{}}
+
+	known := map[string]bool{}
+	knownIDs := map[string]bool{}
+	var names []string
+	addName := func(name string) {
+		if name != "" && !known[name] {
+			known[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, room := range rooms {
+		if strings.EqualFold(room.LocationId, locationID) {
+			addName(room.Name)
+			addName(room.ExternalId)
+			knownIDs[room.ExternalId] = true
+		}
+	}
+	for _, group := range groups {
+		addName(group.Name)
+		addName(group.ExternalId)
+	}
+	sort.Strings(names)
+
+	mapped := map[string]bool{}
+	check := func(roomGroup, room string) {
+		name, isKnown := room, known
+		if name == "" {
+			name = roomGroup
+		} else if strings.HasPrefix(name, kFunctionRoomIDPrefix) {
+			name, isKnown = strings.TrimPrefix(name, kFunctionRoomIDPrefix), knownIDs
+		}
+		mapped[name] = true
+		if !isKnown[name] {
+			report.UnknownRooms = append(report.UnknownRooms, UnknownMappingRoom{
+				RoomGroup:  roomGroup,
+				Room:       room,
+				NearMisses: nearMisses(name, names),
+			})
+		}
+	}
+	for _, roomGroup := range roomGroups {
+		check(roomGroup.RoomGroup, "")
+		for _, room := range roomGroup.Rooms {
+			check(roomGroup.RoomGroup, room)
+		}
+	}
+
+	inGroup := map[string]bool{}
+	for _, group := range groups {
+		for _, id := range group.ExternalFunctionRoomIds {
+			inGroup[id] = true
+		}
+	}
+	for _, room := range rooms {
+		if !strings.EqualFold(room.LocationId, locationID) || room.Name == "" {
+			continue
+		}
+		if !mapped[room.Name] && !mapped[room.ExternalId] && !inGroup[room.ExternalId] {
+			report.UngroupedRooms = append(report.UngroupedRooms, room.Name)
+		}
+	}
+	sort.Strings(report.UngroupedRooms)
+
+	report.Valid = len(report.UnknownRooms) == 0
+	return report, nil
+}
+
+// nearMisses returns the names that name was probably meant to be, the
+// closest first.
+func nearMisses(name string, names []string) []string {
+	normalized := normalizeRoomName(name)
+	// Short names and IDs are only a character or two apart anyway.
+	maxDistance := 2
+	if len(normalized) < 4 {
+		maxDistance = 0
+	} else if len(normalized) < 6 {
+		maxDistance = 1
+	}
+
+	distances := map[string]int{}
+	var misses []string
+	for _, candidate := range names {
+		distance := editDistance(normalizeRoomName(candidate), normalized)
+		if distance <= maxDistance {
+			distances[candidate] = distance
+			misses = append(misses, candidate)
+		}
+	}
+	sort.SliceStable(misses, func(i, j int) bool {
+		return distances[misses[i]] < distances[misses[j]]
+	})
+	return misses
+}
+
+// normalizeRoomName lower cases name and collapses its whitespace.
+func normalizeRoomName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// runMappingCheck checks the mapping file for -check-mapping, returning the
+// exit status.
+func runMappingCheck(locationID string) int {
+	roomGroups, err := readJSONMapping(mappingPath)
+	if err == nil {
+		var report MappingReport
+		report, err = checkMapping(context.Background(), locationID, roomGroups)
+		if err == nil {
+			printMappingReport(os.Stdout, report)
+			if !report.Valid {
+				return 1
+			}
+			return 0
+		}
+	}
+	fmt.Fprintln(os.Stderr, "could not check the mapping:", err)
+	return 2
+}
+
+// printMappingReport writes report for someone running the check by hand.
+func printMappingReport(w io.Writer, report MappingReport) {
+	fmt.Fprintf(w, "%s at location %s:\n", mappingPath, report.LocationId)
+	if len(report.UnknownRooms) == 0 {
+		fmt.Fprintln(w, "  every room is known")
+	}
+	for _, unknown := range report.UnknownRooms {
+		if unknown.Room == "" {
+			fmt.Fprintf(w, "  unknown room group %q", unknown.RoomGroup)
+		} else {
+			fmt.Fprintf(w, "  unknown room %q in room group %q", unknown.Room, unknown.RoomGroup)
+		}
+		if len(unknown.NearMisses) > 0 {
+			quoted := make([]string, len(unknown.NearMisses))
+			for i, miss := range unknown.NearMisses {
+				quoted[i] = fmt.Sprintf("%q", miss)
+			}
+			fmt.Fprintf(w, ", did you mean %s?", strings.Join(quoted, " or "))
+		}
+		fmt.Fprintln(w)
+	}
+	for _, room := range report.UngroupedRooms {
+		fmt.Fprintf(w, "  room %q is in no group\n", room)
+	}
+}
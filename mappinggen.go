@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generatedMapping is the room groups built from AHWS by mappingGenerator,
+// which the mapping file's room groups override. Guarded by mappingMu.
+var generatedMapping []RoomGroups
+
+// mappingGenerator builds the room group mapping from the function room groups
+// set up in Delphi for locationIDs, every interval. A group in the mapping
+// file replaces the generated group with the same name.
+func mappingGenerator(ctx context.Context, interval time.Duration, locationIDs []string) {
+	if len(locationIDs) == 0 {
+		return
+	}
+	DebugPrint("generating the room group mapping for "+strings.Join(locationIDs, ",")+" every "+interval.String(), DebugLevelVerbose)
+
+	refreshGeneratedMapping(ctx, locationIDs)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshGeneratedMapping(ctx, locationIDs)
+		}
+	}
+}
+
+// refreshGeneratedMapping generates the mapping again and puts it into effect.
+// If AHWS can't be reached the last generated mapping stays in use.
+func refreshGeneratedMapping(ctx context.Context, locationIDs []string) {
+	roomGroups, err := generateMapping(ctx, locationIDs)
+	if err != nil {
+		LogError(err)
+		return
+	}
+
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	generatedMapping = roomGroups
+	applyMapping(fileMapping)
+	DebugPrint("generated "+strconv.Itoa(len(roomGroups))+" room groups from AHWS", DebugLevelVerbose)
+}
+
+// generateMapping makes a room group of each function room group at
+// locationIDs, named as events booked into the whole group are, with the
+// external IDs of its function rooms, prefixed with kFunctionRoomIDPrefix.
+func generateMapping(ctx context.Context, locationIDs []string) ([]RoomGroups, error) {
+	groups, err := apiClient.GetFunctionRoomGroup(ctx, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var roomGroups []RoomGroups
+	names := map[string]bool{}
+	for _, group := range groups {
+		roomGroup := RoomGroups{RoomGroup: strings.TrimSpace(group.Name)}
+		seen := map[string]bool{}
+		for _, id := range group.ExternalFunctionRoomIds {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				roomGroup.Rooms = append(roomGroup.Rooms, kFunctionRoomIDPrefix+id)
+			}
+		}
+
+		if validateRoomGroup(roomGroup) != nil {
+			DebugPrint("skipping function room group "+group.Name+" at "+group.LocationId+", it has no name or function rooms", DebugLevelVerbose)
+			continue
+		}
+		if names[roomGroup.RoomGroup] {
+			DebugPrint("skipping function room group "+group.Name+" at "+group.LocationId+", the name is already used", DebugLevelErrors)
+			continue
+		}
+		names[roomGroup.RoomGroup] = true
+		roomGroups = append(roomGroups, roomGroup)
+	}
+	return roomGroups, nil
+}
+
+// mergeMapping puts the overrides on top of the generated room groups.
+func mergeMapping(generated []RoomGroups, overrides []RoomGroups) []RoomGroups {
+	merged := map[string]RoomGroups{}
+	for _, roomGroup := range generated {
+		merged[roomGroup.RoomGroup] = roomGroup
+	}
+	for _, roomGroup := range overrides {
+		merged[roomGroup.RoomGroup] = roomGroup
+	}
+
+	roomGroups := make([]RoomGroups, 0, len(merged))
+	for _, roomGroup := range merged {
+		roomGroups = append(roomGroups, roomGroup)
+	}
+	sort.Slice(roomGroups, func(i, j int) bool {
+		return roomGroups[i].RoomGroup < roomGroups[j].RoomGroup
+	})
+	return roomGroups
+}
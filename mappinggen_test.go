@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"example.com/m/v2/ahws"
+)
+
+func TestGenerateMapping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/2.0/OAuth2"):
+			w.Write([]byte(`{"access_token":"token","expires_in":900,"refresh_token":"refresh","token_type":"bearer"}`))
+		case r.URL.Path == "/api/functionroomgroup/Search":
+			w.Write([]byte(`[
+				{"Id":"G1","Name":"Ballroom ","LocationId":"LOC1","ExternalFunctionRoomIds":["E1","E2","E1"]},
+				{"Id":"G2","Name":"Empty","LocationId":"LOC1","ExternalFunctionRoomIds":[]},
+				{"Id":"G3","Name":"Ballroom","LocationId":"LOC2","ExternalFunctionRoomIds":["E7"]},
+				{"Id":"G4","Name":"Salon","LocationId":"LOC2","ExternalFunctionRoomIds":["E8"," "]}
+			]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	savedClient := apiClient
+	defer func() { apiClient = savedClient }()
+	apiClient = ahws.NewClient(ahws.Credentials{ClientID: "id", Username: "user", Password: "password", SubscriptionKey: "key"}, server.URL, nil, nil)
+	defer apiClient.Close()
+
+	roomGroups, err := generateMapping(context.Background(), []string{"LOC1", "LOC2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RoomGroups{
+		{RoomGroup: "Ballroom", Rooms: []string{"id:E1", "id:E2"}},
+		{RoomGroup: "Salon", Rooms: []string{"id:E8"}},
+	}
+	if !reflect.DeepEqual(roomGroups, want) {
+		t.Errorf("got %+v, want %+v", roomGroups, want)
+	}
+}
+
+// Room groups edited by hand in the mapping file replace the generated ones
+// with the same name, and are kept when AHWS has no such group.
+func TestMergeMapping(t *testing.T) {
+	generated := []RoomGroups{
+		{RoomGroup: "Salon", Rooms: []string{"E8"}},
+		{RoomGroup: "Ballroom", Rooms: []string{"E1", "E2"}},
+	}
+	overrides := []RoomGroups{
+		{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A", "Ballroom B", "Foyer"}},
+		{RoomGroup: "Terrace", Rooms: []string{"Terrace North", "Terrace South"}},
+	}
+	want := []RoomGroups{
+		{RoomGroup: "Ballroom", Rooms: []string{"Ballroom A", "Ballroom B", "Foyer"}},
+		{RoomGroup: "Salon", Rooms: []string{"E8"}},
+		{RoomGroup: "Terrace", Rooms: []string{"Terrace North", "Terrace South"}},
+	}
+	if got := mergeMapping(generated, overrides); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := mergeMapping(nil, overrides); !reflect.DeepEqual(got, []RoomGroups{overrides[0], overrides[1]}) {
+		t.Errorf("without generated groups got %+v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+// kPrefetchInterval is comfortably inside the 15 minute cache TTL, so screens
+// never see an expired entry for a prefetched location.
+const kPrefetchInterval time.Duration = time.Minute * 5
+
+// prefetcher keeps the cache warm for the locations and function room groups
+// the screens are configured for, so the view handlers don't wait on AHWS.
+func prefetcher(ctx context.Context, interval time.Duration, locationIDs []string, groupIDs []string) {
+	if len(locationIDs) == 0 {
+		return
+	}
+	DebugPrint("prefetching "+strings.Join(locationIDs, ",")+" every "+interval.String(), DebugLevelVerbose)
+
+	prefetch(ctx, locationIDs, groupIDs)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prefetch(ctx, locationIDs, groupIDs)
+		}
+	}
+}
+
+func prefetch(ctx context.Context, locationIDs []string, groupIDs []string) {
+	start := time.Now()
+
+	// Locations first, the time zone decides the date range searched.
+	_, err := apiClient.RefreshLocationsByID(ctx)
+	LogError(err)
+
+	groups, err := apiClient.RefreshFunctionRoomGroups(ctx, locationIDs)
+	if err != nil {
+		LogError(err)
+		// Fall back to whatever is cached to pair groups with locations.
+		groups, _ = apiClient.GetFunctionRoomGroup(ctx, locationIDs)
+	}
+	groupsAt := groupsByLocation(groups, locationIDs, groupIDs)
+
+	for _, locationID := range locationIDs {
+		loc := LocationTimeZone(ctx, locationID)
+
+		// Cover screens look up their room and room group members.
+		_, err := apiClient.RefreshFunctionRoomsAtLocation(ctx, locationID)
+		LogError(err)
+
+		// The whole location for cover screens, and each of its groups for
+		// schedule screens filtered by group-id.
+		for _, groupID := range append([]string{""}, groupsAt[locationID]...) {
+			if ctx.Err() != nil {
+				return
+			}
+			_, err := apiClient.RefreshDefiniteEvents(ctx, todaysEventSearch(locationID, groupID, loc))
+			LogError(err)
+		}
+	}
+	DebugPrint("prefetch finished in "+time.Since(start).String(), DebugLevelVerbose)
+}
+
+// groupsByLocation pairs each of groupIDs with the one of locationIDs it's
+// at, so a group is only searched where it exists. Groups that aren't at any
+// of the locations are logged and left out.
+func groupsByLocation(groups []ahws.FunctionRoomGroupsResponse, locationIDs []string, groupIDs []string) map[string][]string {
+	groupsAt := map[string][]string{}
+	for _, groupID := range groupIDs {
+		found := false
+		for _, group := range groups {
+			if !strings.EqualFold(group.Id, groupID) && !strings.EqualFold(group.ExternalId, groupID) {
+				continue
+			}
+			for _, locationID := range locationIDs {
+				if strings.EqualFold(group.LocationId, locationID) {
+					groupsAt[locationID] = append(groupsAt[locationID], groupID)
+					found = true
+					break
+				}
+			}
+			break
+		}
+		if !found {
+			DebugPrint("prefetch group "+groupID+" isn't at any of "+strings.Join(locationIDs, ","), DebugLevelErrors)
+		}
+	}
+	return groupsAt
+}
+
+// listFromEnv splits a comma separated environment variable.
+func listFromEnv(name string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
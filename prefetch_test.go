@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"example.com/m/v2/ahws"
+)
+
+func TestGroupsByLocation(t *testing.T) {
+	groups := []ahws.FunctionRoomGroupsResponse{
+		{Id: "G1", LocationId: "LOC1"},
+		{Id: "G2", ExternalId: "EXT2", LocationId: "LOC2"},
+		{Id: "G3", LocationId: "LOC3"},
+	}
+	got := groupsByLocation(groups, []string{"LOC1", "LOC2"}, []string{"G1", "EXT2", "G3", "G4"})
+	want := map[string][]string{"LOC1": {"G1"}, "LOC2": {"EXT2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupsByLocation = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kMaxScheduleDays limits how far a single schedule board can search.
+const kMaxScheduleDays int = 31
+
+// ScheduleOptions are what a schedule board shows, read from its query
+// parameters.
+type ScheduleOptions struct {
+	From time.Time
+	To   time.Time
+	// ShowPast keeps events that finished more than schedulePastGrace ago.
+	ShowPast bool
+
+	RowsPerPage  int
+	GroupPerPage bool
+	PageSeconds  int
+}
+
+var (
+	// schedulePastGrace is how long a finished event stays on a board
+	// showing today.
+	schedulePastGrace = time.Minute * 15
+
+	// Boards longer than scheduleRowsPerPage rows are split into pages shown
+	// for schedulePageSeconds each. 0 rows shows everything on one page.
+	scheduleRowsPerPage  = 14
+	schedulePageSeconds  = 10
+	scheduleGroupPerPage = false
+)
+
+func scheduleOptions(query url.Values, loc *time.Location) (ScheduleOptions, error) {
+	from, to, err := scheduleRange(query, loc)
+	if err != nil {
+		return ScheduleOptions{}, err
+	}
+	options := ScheduleOptions{
+		From:         from,
+		To:           to,
+		RowsPerPage:  scheduleRowsPerPage,
+		GroupPerPage: scheduleGroupPerPage,
+		PageSeconds:  schedulePageSeconds,
+	}
+	if value := query.Get("show-past"); value != "" {
+		if options.ShowPast, err = strconv.ParseBool(value); err != nil {
+			return ScheduleOptions{}, errors.New("show-past: " + err.Error())
+		}
+	}
+	if value := query.Get("rows"); value != "" {
+		if options.RowsPerPage, err = strconv.Atoi(value); err != nil || options.RowsPerPage < 0 {
+			return ScheduleOptions{}, errors.New("rows must be a number, 0 for no pages")
+		}
+	}
+	if value := query.Get("group-per-page"); value != "" {
+		if options.GroupPerPage, err = strconv.ParseBool(value); err != nil {
+			return ScheduleOptions{}, errors.New("group-per-page: " + err.Error())
+		}
+	}
+	if value := query.Get("page-seconds"); value != "" {
+		if options.PageSeconds, err = strconv.Atoi(value); err != nil || options.PageSeconds < 1 {
+			return ScheduleOptions{}, errors.New("page-seconds must be a positive number")
+		}
+	}
+	return options, nil
+}
+
+// scheduleRange reads the dates a schedule board shows from the query
+// parameters, either date (YYYY-MM-DD, today or tomorrow) and days, or from
+// and to (inclusive). It defaults to today at the location. The returned end
+// is midnight after the last day.
+func scheduleRange(query url.Values, loc *time.Location) (time.Time, time.Time, error) {
+	today := startOfDay(time.Now().In(loc))
+
+	hasDate := query.Get("date") != "" || query.Get("days") != ""
+	hasFromTo := query.Get("from") != "" || query.Get("to") != ""
+	if hasDate && hasFromTo {
+		return time.Time{}, time.Time{}, errors.New("date and days can't be combined with from and to")
+	}
+
+	if hasFromTo {
+		if query.Get("from") == "" || query.Get("to") == "" {
+			return time.Time{}, time.Time{}, errors.New("from and to must both be provided")
+		}
+		from, err := parseScheduleDate(query.Get("from"), today)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from: " + err.Error())
+		}
+		to, err := parseScheduleDate(query.Get("to"), today)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to: " + err.Error())
+		}
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, errors.New("to is before from")
+		}
+		return checkScheduleDays(from, daysBetween(from, to)+1)
+	}
+
+	from := today
+	if value := query.Get("date"); value != "" {
+		var err error
+		if from, err = parseScheduleDate(value, today); err != nil {
+			return time.Time{}, time.Time{}, errors.New("date: " + err.Error())
+		}
+	}
+	days := 1
+	if value := query.Get("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil || days < 1 {
+			return time.Time{}, time.Time{}, errors.New("days must be a positive number")
+		}
+	}
+	return checkScheduleDays(from, days)
+}
+
+func checkScheduleDays(from time.Time, days int) (time.Time, time.Time, error) {
+	if days > kMaxScheduleDays {
+		return time.Time{}, time.Time{}, errors.New("at most " + strconv.Itoa(kMaxScheduleDays) + " days can be shown")
+	}
+	return from, from.AddDate(0, 0, days), nil
+}
+
+func parseScheduleDate(value string, today time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), today.Location())
+	if err != nil {
+		return time.Time{}, errors.New("expected YYYY-MM-DD, got " + value)
+	}
+	return date, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestScheduleRange(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	today := startOfDay(time.Now().In(loc))
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		query    string
+		from, to time.Time
+		err      bool
+	}{
+		{query: "", from: today, to: today.AddDate(0, 0, 1)},
+		{query: "date=2024-06-03", from: date(2024, time.June, 3), to: date(2024, time.June, 4)},
+		{query: "date=2024-06-03&days=3", from: date(2024, time.June, 3), to: date(2024, time.June, 6)},
+		{query: "days=2", from: today, to: today.AddDate(0, 0, 2)},
+		{query: "date=today", from: today, to: today.AddDate(0, 0, 1)},
+		{query: "date=Tomorrow", from: today.AddDate(0, 0, 1), to: today.AddDate(0, 0, 2)},
+		{query: "from=2024-06-03&to=2024-06-05", from: date(2024, time.June, 3), to: date(2024, time.June, 6)},
+		{query: "from=2024-06-03&to=2024-06-03", from: date(2024, time.June, 3), to: date(2024, time.June, 4)},
+		{query: "from=today&to=tomorrow", from: today, to: today.AddDate(0, 0, 2)},
+		// Clocks go forward on March 10th, the end is still midnight.
+		{query: "from=2024-03-09&to=2024-03-11", from: date(2024, time.March, 9), to: date(2024, time.March, 12)},
+		{query: "date=2024-11-02&days=2", from: date(2024, time.November, 2), to: date(2024, time.November, 4)},
+		{query: "date=2024-01-01&days=31", from: date(2024, time.January, 1), to: date(2024, time.February, 1)},
+		{query: "from=2024-01-01&to=2024-01-31", from: date(2024, time.January, 1), to: date(2024, time.February, 1)},
+
+		{query: "date=2024-01-01&days=32", err: true},
+		{query: "from=2024-01-01&to=2024-02-01", err: true},
+		{query: "date=2024-06-03&from=2024-06-03&to=2024-06-04", err: true},
+		{query: "days=2&to=2024-06-04", err: true},
+		{query: "from=2024-06-03", err: true},
+		{query: "from=2024-06-05&to=2024-06-03", err: true},
+		{query: "date=06/03/2024", err: true},
+		{query: "days=0", err: true},
+		{query: "days=many", err: true},
+	}
+	for _, test := range tests {
+		query, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, to, err := scheduleRange(query, loc)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: got %v to %v, want an error", test.query, from, to)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.query, err)
+			continue
+		}
+		if !from.Equal(test.from) || !to.Equal(test.to) {
+			t.Errorf("%q: got %v to %v, want %v to %v", test.query, from, to, test.from, test.to)
+		}
+		if to.Hour() != 0 || to.Minute() != 0 {
+			t.Errorf("%q: ends at %v, want midnight", test.query, to)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+)
+
+type (
+	// SchedulePage is one screenful of a schedule board, shown in turn with
+	// the others.
+	SchedulePage struct {
+		Number   int
+		Sections []ScheduleSection
+	}
+
+	// ScheduleSection is a group's events on a page. A group that doesn't fit
+	// on one page is continued on the next.
+	ScheduleSection struct {
+		Day       string
+		ShowDay   bool
+		Group     string
+		Continued bool
+		Events    []ScheduleEvent
+	}
+)
+
+// paginateSchedule splits the board into pages of at most rows rows, counting
+// day and group headings as a row each. rows 0 puts everything on one page,
+// and groupPerPage starts every group on a new page.
+func paginateSchedule(events ScheduleScreen, rows int, groupPerPage bool) []SchedulePage {
+	var pages []SchedulePage
+	var page SchedulePage
+	used := 0
+	lastDay := ""
+
+	flush := func() {
+		if len(page.Sections) > 0 {
+			page.Number = len(pages) + 1
+			pages = append(pages, page)
+		}
+		page = SchedulePage{}
+		used = 0
+	}
+
+	addGroups := func(day string, groups map[string][]ScheduleEvent) {
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if groupPerPage {
+				flush()
+			}
+			remaining := groups[name]
+			continued := false
+			for len(remaining) > 0 {
+				showDay := day != "" && (len(page.Sections) == 0 || day != lastDay)
+				headings := 1
+				if showDay {
+					headings++
+				}
+				// Start a new page unless the headings and a row still fit.
+				if rows > 0 && used > 0 && used+headings+1 > rows {
+					flush()
+					showDay = day != ""
+					headings = 1
+					if showDay {
+						headings++
+					}
+				}
+
+				n := len(remaining)
+				if rows > 0 && n > rows-used-headings {
+					n = rows - used - headings
+				}
+				if n < 1 {
+					n = 1
+				}
+				page.Sections = append(page.Sections, ScheduleSection{
+					Day:       day,
+					ShowDay:   showDay,
+					Group:     name,
+					Continued: continued,
+					Events:    remaining[:n],
+				})
+				used += headings + n
+				lastDay = day
+				remaining = remaining[n:]
+				continued = true
+			}
+		}
+	}
+
+	if len(events.Days) > 0 {
+		for _, day := range events.Days {
+			addGroups(day.Label, day.Groups)
+		}
+	} else {
+		addGroups("", events.Groups)
+	}
+	flush()
+	return pages
+}
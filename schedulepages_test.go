@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPaginateSchedule(t *testing.T) {
+	events := func(n int) []ScheduleEvent {
+		return make([]ScheduleEvent, n)
+	}
+	groups := func(counts map[string]int) map[string][]ScheduleEvent {
+		groups := map[string][]ScheduleEvent{}
+		for name, n := range counts {
+			groups[name] = events(n)
+		}
+		return groups
+	}
+	days := func(counts ...map[string]int) []ScheduleDay {
+		var days []ScheduleDay
+		for i, count := range counts {
+			days = append(days, ScheduleDay{Label: "Day " + strconv.Itoa(i+1), Groups: groups(count)})
+		}
+		return days
+	}
+
+	tests := []struct {
+		name         string
+		screen       ScheduleScreen
+		rows         int
+		groupPerPage bool
+		// want is each page's sections, as "day/" when the day heading is
+		// shown, the group, "(continued)" and the number of events.
+		want [][]string
+	}{
+		{
+			name:   "fits on one page",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Host": 3})},
+			rows:   5,
+			want:   [][]string{{"Host 3"}},
+		},
+		{
+			name:   "exact multiple",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Host": 8})},
+			rows:   5,
+			want:   [][]string{{"Host 4"}, {"Host (continued) 4"}},
+		},
+		{
+			name:   "partial last page",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Host": 10})},
+			rows:   5,
+			want:   [][]string{{"Host 4"}, {"Host (continued) 4"}, {"Host (continued) 2"}},
+		},
+		{
+			name:   "group continued on the next page",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Guest": 2, "Host": 5})},
+			rows:   5,
+			want:   [][]string{{"Guest 2", "Host 1"}, {"Host (continued) 4"}},
+		},
+		{
+			name:   "no room for another heading",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Guest": 3, "Host": 2})},
+			rows:   5,
+			want:   [][]string{{"Guest 3"}, {"Host 2"}},
+		},
+		{
+			name:   "day headings",
+			screen: ScheduleScreen{Days: days(map[string]int{"Guest": 1, "Host": 2}, map[string]int{"Host": 1})},
+			rows:   10,
+			want:   [][]string{{"Day 1/Guest 1", "Host 2", "Day 2/Host 1"}},
+		},
+		{
+			name:   "day heading repeated on a continued page",
+			screen: ScheduleScreen{Days: days(map[string]int{"Host": 5}, map[string]int{"Host": 2})},
+			rows:   5,
+			want:   [][]string{{"Day 1/Host 3"}, {"Day 1/Host (continued) 2"}, {"Day 2/Host 2"}},
+		},
+		{
+			name:         "group per page",
+			screen:       ScheduleScreen{Groups: groups(map[string]int{"Guest": 1, "Host": 2})},
+			rows:         5,
+			groupPerPage: true,
+			want:         [][]string{{"Guest 1"}, {"Host 2"}},
+		},
+		{
+			name:         "group per page with no row limit",
+			screen:       ScheduleScreen{Groups: groups(map[string]int{"Guest": 10, "Host": 20})},
+			groupPerPage: true,
+			want:         [][]string{{"Guest 10"}, {"Host 20"}},
+		},
+		{
+			name:   "no row limit",
+			screen: ScheduleScreen{Groups: groups(map[string]int{"Guest": 10, "Host": 20})},
+			want:   [][]string{{"Guest 10", "Host 20"}},
+		},
+		{
+			name:   "nothing to show",
+			screen: ScheduleScreen{Groups: groups(nil)},
+			rows:   5,
+		},
+	}
+	for _, test := range tests {
+		var got [][]string
+		for i, page := range paginateSchedule(test.screen, test.rows, test.groupPerPage) {
+			if page.Number != i+1 {
+				t.Errorf("%s: page %d is numbered %d", test.name, i+1, page.Number)
+			}
+			var sections []string
+			for _, section := range page.Sections {
+				s := section.Group
+				if section.ShowDay {
+					s = section.Day + "/" + s
+				}
+				if section.Continued {
+					s += " (continued)"
+				}
+				sections = append(sections, s+" "+strconv.Itoa(len(section.Events)))
+			}
+			got = append(got, sections)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
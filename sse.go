@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+const (
+	// kSSEPollInterval is how often a watched screen's events are searched
+	// again. Searches are answered from the cache until it expires, which
+	// also picks up another instance's refresh, and time moving a screen on
+	// is noticed within an interval.
+	kSSEPollInterval time.Duration = time.Second * 15
+	// kSSEKeepAlive stops proxies closing idle event streams.
+	kSSEKeepAlive time.Duration = time.Second * 30
+)
+
+type (
+	// ScreenUpdate is sent to a screen when the events it shows change.
+	ScreenUpdate struct {
+		Version   string
+		FetchedAt time.Time
+	}
+
+	// screenHub runs one watcher per distinct event search, shared by every
+	// screen subscribed to it.
+	screenHub struct {
+		mu       sync.Mutex
+		watchers map[string]*screenWatcher
+	}
+
+	screenWatcher struct {
+		subscribers map[chan ScreenUpdate]bool
+		last        ScreenUpdate
+		cancel      context.CancelFunc
+	}
+)
+
+var screenUpdates = &screenHub{watchers: map[string]*screenWatcher{}}
+
+// screenBoundaries are the times around an event from start to end at which
+// a screen showing it changes.
+type screenBoundaries func(start time.Time, end time.Time) []time.Time
+
+// coverBoundaries are an event starting soon, starting and ending.
+func coverBoundaries(start time.Time, end time.Time) []time.Time {
+	return []time.Time{start.Add(-startingSoonWindow), start, end}
+}
+
+// scheduleBoundaries are an event starting, ending and dropping off a board
+// showing today.
+func scheduleBoundaries(start time.Time, end time.Time) []time.Time {
+	return []time.Time{start, end, end.Add(schedulePastGrace)}
+}
+
+// screenVersion fingerprints events as a screen at loc shows them at now, so
+// screens can tell whether what they show is current. Besides the events it
+// covers the day and how many of the events' boundaries for the screen have
+// passed.
+func screenVersion(events []ahws.DefiniteEventSearchResponse, loc *time.Location, now time.Time, boundaries screenBoundaries) string {
+	body, err := json.Marshal(events)
+	if err != nil {
+		LogError(err)
+		return ""
+	}
+
+	passed := 0
+	for _, event := range events {
+		start, end, err := parseEventSpan(event, loc)
+		if err != nil {
+			continue
+		}
+		for _, boundary := range boundaries(start, end) {
+			if !now.Before(boundary) {
+				passed++
+			}
+		}
+	}
+	body = append(body, fmt.Sprintf("%s/%d", now.In(loc).Format("2006-01-02"), passed)...)
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:8])
+}
+
+// subscribe returns a channel of updates to the screens watched under key,
+// starting with the current version once it is known. search is called each
+// poll, so searches relative to today move on at midnight.
+func (h *screenHub) subscribe(key string, loc *time.Location, boundaries screenBoundaries, search func() ahws.DefiniteEventSearchRequest) (<-chan ScreenUpdate, func()) {
+	updates := make(chan ScreenUpdate, 1)
+
+	h.mu.Lock()
+	watcher, ok := h.watchers[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		watcher = &screenWatcher{subscribers: map[chan ScreenUpdate]bool{}, cancel: cancel}
+		h.watchers[key] = watcher
+		go h.watch(ctx, watcher, loc, boundaries, search)
+	}
+	watcher.subscribers[updates] = true
+	if watcher.last.Version != "" {
+		updates <- watcher.last
+	}
+	h.mu.Unlock()
+
+	return updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(watcher.subscribers, updates)
+		if len(watcher.subscribers) == 0 {
+			watcher.cancel()
+			delete(h.watchers, key)
+		}
+	}
+}
+
+func (h *screenHub) watch(ctx context.Context, watcher *screenWatcher, loc *time.Location, boundaries screenBoundaries, search func() ahws.DefiniteEventSearchRequest) {
+	ticker := time.NewTicker(kSSEPollInterval)
+	defer ticker.Stop()
+	for {
+		result, err := apiClient.SearchDefiniteEvents(ctx, search())
+		if err == nil {
+			h.publish(watcher, ScreenUpdate{Version: screenVersion(result.Events, loc, time.Now(), boundaries), FetchedAt: result.FetchedAt})
+		} else if ctx.Err() == nil {
+			LogError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *screenHub) publish(watcher *screenWatcher, update ScreenUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if update.Version == watcher.last.Version {
+		return
+	}
+	watcher.last = update
+	for subscriber := range watcher.subscribers {
+		// Only the latest version matters to a slow subscriber.
+		select {
+		case <-subscriber:
+		default:
+		}
+		subscriber <- update
+	}
+}
+
+// serveScreenUpdates streams the updates to the screens watched under key to
+// the screen as Server-Sent Events, until it disconnects.
+func serveScreenUpdates(w http.ResponseWriter, r *http.Request, key string, loc *time.Location, boundaries screenBoundaries, search func() ahws.DefiniteEventSearchRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("streaming unsupported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx, in front of Passenger, from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 10000\n\n")
+	flusher.Flush()
+
+	updates, unsubscribe := screenUpdates.subscribe(key, loc, boundaries, search)
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(kSSEKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-updates:
+			body, err := json.Marshal(update)
+			if err != nil {
+				LogError(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: update\ndata: %s\n\n", body)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"example.com/m/v2/ahws"
+)
+
+// Screens are pushed an update when time moves them on, not only when the
+// events change, but only at the times that change what each screen shows.
+func TestScreenVersionFollowsTime(t *testing.T) {
+	loc := time.UTC
+	start := time.Date(2026, time.October, 18, 22, 0, 0, 0, loc)
+	room := FunctionRoom{ID: "E3", Name: "Boardroom"}
+	events := []ahws.DefiniteEventSearchResponse{testEvent("Late Night", room, start, start.Add(time.Hour*3))}
+
+	for _, screen := range []struct {
+		name       string
+		boundaries screenBoundaries
+		changes    map[string]bool
+	}{
+		{"cover", coverBoundaries, map[string]bool{"starting soon": true, "start": true, "midnight": true, "end": true}},
+		{"schedule", scheduleBoundaries, map[string]bool{"start": true, "midnight": true, "end": true, "off the board": true}},
+	} {
+		at := func(offset time.Duration) string {
+			return screenVersion(events, loc, start.Add(offset), screen.boundaries)
+		}
+		if at(-time.Hour) != at(-time.Minute*30) {
+			t.Errorf("%s: version changed without anything on screen changing", screen.name)
+		}
+		for _, change := range []struct {
+			name          string
+			before, after time.Duration
+		}{
+			{"starting soon", -startingSoonWindow - time.Minute, -startingSoonWindow},
+			{"start", -time.Minute, 0},
+			{"midnight", time.Hour + time.Minute*59, time.Hour * 2},
+			{"end", time.Hour*3 - time.Second, time.Hour * 3},
+			{"off the board", time.Hour*3 + schedulePastGrace - time.Minute, time.Hour*3 + schedulePastGrace},
+		} {
+			if changed := at(change.before) != at(change.after); changed != screen.changes[change.name] {
+				t.Errorf("%s: version changed at %s is %v, want %v", screen.name, change.name, changed, screen.changes[change.name])
+			}
+		}
+
+		if screenVersion(nil, loc, start, screen.boundaries) == screenVersion(nil, loc, start.Add(time.Hour*2), screen.boundaries) {
+			t.Errorf("%s: version didn't change at midnight without events", screen.name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	kScheduleTemplate string = "schedule_screen.html.template"
+	kCoverTemplate    string = "cover_screen.html.template"
+	// kRefreshTemplate is the script every screen uses to swap in updates.
+	kRefreshTemplate string = "screen_refresh.html.template"
+)
+
+//go:embed *.html.template
+var embeddedTemplates embed.FS
+
+// screenTemplates are parsed once at startup, from the binary or templateDir.
+// In dev mode they're parsed again whenever a file in templateDir changes.
+var screenTemplates = &templateSet{
+	names:  []string{kScheduleTemplate, kCoverTemplate},
+	shared: []string{kRefreshTemplate},
+}
+
+type templateSet struct {
+	names []string
+	// shared are parsed along with each of names, for the templates they
+	// define.
+	shared []string
+	dir    string
+	dev    bool
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+	modTimes  map[string]time.Time
+}
+
+// load parses the templates from dir, or the embedded copies if dir is empty.
+// It's called once at startup, dev mode reads the files from the working
+// directory unless there is a dir.
+func (t *templateSet) load(dir string, dev bool) error {
+	if dev && dir == "" {
+		dir = "."
+	}
+	t.dir, t.dev = dir, dev
+	return t.parse()
+}
+
+func (t *templateSet) parse() error {
+	var fsys fs.FS = embeddedTemplates
+	if t.dir != "" {
+		fsys = os.DirFS(t.dir)
+	}
+
+	templates := map[string]*template.Template{}
+	for _, name := range t.names {
+		tmpl, err := template.ParseFS(fsys, append([]string{name}, t.shared...)...)
+		if err != nil {
+			return err
+		}
+		templates[name] = tmpl
+	}
+
+	t.mu.Lock()
+	t.templates = templates
+	t.modTimes = t.readModTimes()
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *templateSet) readModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	if t.dir == "" {
+		return modTimes
+	}
+	for _, name := range append(t.names, t.shared...) {
+		if info, err := os.Stat(filepath.Join(t.dir, name)); err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// get returns the named template, reloading them first in dev mode if the
+// files changed.
+func (t *templateSet) get(name string) (*template.Template, error) {
+	if t.dev {
+		t.mu.RLock()
+		changed := false
+		for file, modTime := range t.readModTimes() {
+			if !modTime.Equal(t.modTimes[file]) {
+				changed = true
+			}
+		}
+		t.mu.RUnlock()
+
+		if changed {
+			DebugPrint("reloading templates from "+t.dir, DebugLevelVerbose)
+			if err := t.parse(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return nil, errors.New("unknown template: " + name)
+	}
+	return tmpl, nil
+}
+
+// render executes the named template, so a failure part way through doesn't
+// send half a page.
+func (t *templateSet) render(w http.ResponseWriter, name string, data any) {
+	var page bytes.Buffer
+	tmpl, err := t.get(name)
+	if err == nil {
+		err = tmpl.Execute(&page, data)
+	}
+	if err != nil {
+		LogError(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("could not render " + name))
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	w.Write(page.Bytes())
+}
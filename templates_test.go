@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScreenTemplates(t *testing.T) {
+	templates := &templateSet{names: screenTemplates.names, shared: screenTemplates.shared}
+	if err := templates.load("", false); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]any{
+		kCoverTemplate:    CoverScreen{Stale: true, Version: "v1", LastUpdated: "09:00 AM"},
+		kScheduleTemplate: ScheduleScreen{Stale: true, Version: "v1", LastUpdated: "09:00 AM"},
+	} {
+		w := httptest.NewRecorder()
+		templates.render(w, name, data)
+		page := w.Body.String()
+
+		_, main, _ := strings.Cut(page, "<main>")
+		main, _, _ = strings.Cut(main, "</main>")
+		if !strings.Contains(main, `class="stale_indicator"`) {
+			t.Errorf("%s: the stale indicator isn't in <main>, so refreshes don't update it", name)
+		}
+		if !strings.Contains(page, "function refreshMain") || !strings.Contains(page, `let version = "v1"`) {
+			t.Errorf("%s: the refresh script is missing", name)
+		}
+	}
+}
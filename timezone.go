@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kDefaultTimeZone string = "America/New_York"
+
+var (
+	// defaultTimeZone is used when a location has no usable TimeZone, or the
+	// location lookup fails.
+	defaultTimeZone = kDefaultTimeZone
+
+	// loadedZones caches *time.Location by IANA name, time.LoadLocation reads
+	// the zoneinfo database from disk on every call.
+	loadedZones sync.Map
+
+	utcOffsetPrefix = regexp.MustCompile(`^\((?:UTC|GMT)\s*[+-]?[0-9:]*\)\s*`)
+
+	// AHWS reports location time zones using Windows time zone IDs, or their
+	// display names. This maps both forms to IANA zones.
+	ahwsTimeZones = map[string]string{
+		"Dateline Standard Time":            "Etc/GMT+12",
+		"UTC-11":                            "Etc/GMT+11",
+		"Hawaiian Standard Time":            "Pacific/Honolulu",
+		"Hawaii":                            "Pacific/Honolulu",
+		"Alaskan Standard Time":             "America/Anchorage",
+		"Alaska":                            "America/Anchorage",
+		"Pacific Standard Time":             "America/Los_Angeles",
+		"Pacific Time (US & Canada)":        "America/Los_Angeles",
+		"US Mountain Standard Time":         "America/Phoenix",
+		"Arizona":                           "America/Phoenix",
+		"Mountain Standard Time":            "America/Denver",
+		"Mountain Time (US & Canada)":       "America/Denver",
+		"Central Standard Time":             "America/Chicago",
+		"Central Time (US & Canada)":        "America/Chicago",
+		"Central America Standard Time":     "America/Guatemala",
+		"Central America":                   "America/Guatemala",
+		"Central Standard Time (Mexico)":    "America/Mexico_City",
+		"Eastern Standard Time":             "America/New_York",
+		"Eastern Time (US & Canada)":        "America/New_York",
+		"US Eastern Standard Time":          "America/Indiana/Indianapolis",
+		"Indiana (East)":                    "America/Indiana/Indianapolis",
+		"SA Pacific Standard Time":          "America/Bogota",
+		"Bogota, Lima, Quito, Rio Branco":   "America/Bogota",
+		"Atlantic Standard Time":            "America/Halifax",
+		"Atlantic Time (Canada)":            "America/Halifax",
+		"SA Western Standard Time":          "America/La_Paz",
+		"Newfoundland Standard Time":        "America/St_Johns",
+		"Newfoundland":                      "America/St_Johns",
+		"E. South America Standard Time":    "America/Sao_Paulo",
+		"Brasilia":                          "America/Sao_Paulo",
+		"Argentina Standard Time":           "America/Argentina/Buenos_Aires",
+		"UTC":                               "UTC",
+		"Coordinated Universal Time":        "UTC",
+		"GMT Standard Time":                 "Europe/London",
+		"Dublin, Edinburgh, Lisbon, London": "Europe/London",
+		"W. Europe Standard Time":           "Europe/Berlin",
+		"Romance Standard Time":             "Europe/Paris",
+		"Central Europe Standard Time":      "Europe/Budapest",
+		"Central European Standard Time":    "Europe/Warsaw",
+		"GTB Standard Time":                 "Europe/Bucharest",
+		"FLE Standard Time":                 "Europe/Kiev",
+		"Israel Standard Time":              "Asia/Jerusalem",
+		"Russian Standard Time":             "Europe/Moscow",
+		"Arabian Standard Time":             "Asia/Dubai",
+		"Arab Standard Time":                "Asia/Riyadh",
+		"India Standard Time":               "Asia/Kolkata",
+		"SE Asia Standard Time":             "Asia/Bangkok",
+		"China Standard Time":               "Asia/Shanghai",
+		"Singapore Standard Time":           "Asia/Singapore",
+		"Tokyo Standard Time":               "Asia/Tokyo",
+		"Korea Standard Time":               "Asia/Seoul",
+		"AUS Eastern Standard Time":         "Australia/Sydney",
+		"E. Australia Standard Time":        "Australia/Brisbane",
+		"W. Australia Standard Time":        "Australia/Perth",
+		"New Zealand Standard Time":         "Pacific/Auckland",
+	}
+
+	// Some properties are configured with a bare abbreviation.
+	ahwsTimeZoneAbbreviations = map[string]string{
+		"EST":  "America/New_York",
+		"EDT":  "America/New_York",
+		"CST":  "America/Chicago",
+		"CDT":  "America/Chicago",
+		"MST":  "America/Denver",
+		"MDT":  "America/Denver",
+		"PST":  "America/Los_Angeles",
+		"PDT":  "America/Los_Angeles",
+		"AKST": "America/Anchorage",
+		"HST":  "Pacific/Honolulu",
+		// UK properties say GMT for their local time, BST included.
+		"GMT": "Europe/London",
+		"BST": "Europe/London",
+	}
+)
+
+// ResolveTimeZone maps an AHWS TimeZone value to an IANA zone name.
+func ResolveTimeZone(ahwsTimeZone string) (string, error) {
+	tz := strings.TrimSpace(ahwsTimeZone)
+	if tz == "" {
+		return "", errors.New("empty time zone")
+	}
+
+	if name, ok := ahwsTimeZones[tz]; ok {
+		return name, nil
+	}
+
+	// "(UTC-05:00) Eastern Time (US & Canada)"
+	if stripped := utcOffsetPrefix.ReplaceAllString(tz, ""); stripped != tz {
+		if name, ok := ahwsTimeZones[stripped]; ok {
+			return name, nil
+		}
+	}
+
+	if name, ok := ahwsTimeZoneAbbreviations[strings.ToUpper(tz)]; ok {
+		return name, nil
+	}
+
+	// Already an IANA name.
+	if _, err := loadZone(tz); err == nil {
+		return tz, nil
+	}
+	return "", errors.New("unknown time zone: " + tz)
+}
+
+// LocationTimeZone returns the time zone of an AHWS location, falling back to
+// defaultTimeZone if the location can't be found or its TimeZone is unknown.
+func LocationTimeZone(ctx context.Context, locationID string) *time.Location {
+	if cached, found := apiCache.Get("TimeZoneByLocation:" + locationID); found {
+		if loc, err := loadZone(cached.(string)); err == nil {
+			return loc
+		}
+	}
+
+	locations, err := apiClient.GetLocationsByID(ctx)
+	LogError(err)
+
+	for _, location := range locations {
+		if !strings.EqualFold(location.Id, locationID) {
+			continue
+		}
+		name, err := ResolveTimeZone(location.TimeZone)
+		if err != nil {
+			DebugPrint("location "+locationID+": "+err.Error(), DebugLevelErrors)
+			break
+		}
+		loc, err := loadZone(name)
+		if err != nil {
+			LogError(err)
+			break
+		}
+		apiCache.Set("TimeZoneByLocation:"+locationID, name, time.Hour*24)
+		return loc
+	}
+
+	DebugPrint("using default time zone "+defaultTimeZone+" for location "+locationID, DebugLevelVerbose)
+	loc, err := loadZone(defaultTimeZone)
+	if err != nil {
+		LogError(err)
+		return time.Local
+	}
+	return loc
+}
+
+func loadZone(name string) (*time.Location, error) {
+	if loc, ok := loadedZones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	loadedZones.Store(name, loc)
+	return loc, nil
+}
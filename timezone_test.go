@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestResolveTimeZone(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Eastern Standard Time", "America/New_York"},
+		{"  Pacific Standard Time ", "America/Los_Angeles"},
+		{"Central Time (US & Canada)", "America/Chicago"},
+		{"(UTC-05:00) Eastern Time (US & Canada)", "America/New_York"},
+		{"(GMT-10:00) Hawaii", "Pacific/Honolulu"},
+		{"(UTC) Coordinated Universal Time", "UTC"},
+		{"(UTC+00:00) Dublin, Edinburgh, Lisbon, London", "Europe/London"},
+		{"EST", "America/New_York"},
+		{"pdt", "America/Los_Angeles"},
+		{"GMT", "Europe/London"},
+		{"America/Denver", "America/Denver"},
+		{"UTC", "UTC"},
+		{"", ""},
+		{"Mars Standard Time", ""},
+		{"(UTC+13:00) Nowhere", ""},
+	}
+	for _, test := range tests {
+		got, err := ResolveTimeZone(test.value)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%q: got %q, want an error", test.value, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%q: got %q, %v, want %q", test.value, got, err, test.want)
+		}
+	}
+}